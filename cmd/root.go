@@ -6,28 +6,31 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
 
 	"github.com/erikwj/brokenlinks/internal"
+	"github.com/erikwj/brokenlinks/pkg/brokenlinks"
 	"github.com/spf13/cobra"
 )
 
 // rootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
 	Use:   "brokenlinks",
-	Short: "A cli to validate a markdown tree for broken links",
-	Long: `A cli to validate a markdown tree for broken links
+	Short: "A cli to validate a documentation tree for broken links",
+	Long: `A cli to validate a documentation tree for broken links
 
 	Currently support for:
+	- Markdown, reStructuredText, AsciiDoc and HTML documents
 	- image links in png, svg, or gif format
-	- web links [manually for now]
+	- web links [manually, or checked over HTTP with --check-web]
 	- file links in same directory
-	- internal references to [other] markdown files headers
+	- internal references to [other] documents headers
 	`,
 	// Execution
 	Run: func(cmd *cobra.Command, args []string) {
 		directory := dir
-		extension := ext
 
 		// validate that directory is not empty
 		if directory == "" {
@@ -37,35 +40,100 @@ var RootCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		f := func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if filepath.Ext(path) == extension {
-				if verbose {
-					fmt.Fprintf(cmd.OutOrStdout(), "# Validating %s \n", path)
-				}
-
-				if err := internal.ValidateLinks(path, extension, errors_only); err != nil {
-					fmt.Printf("# Error validating links in file %s: %v\n", path, err)
-				}
-			}
-			return nil
+		newSlugger, err := brokenlinks.SluggerFor(slugStyle)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if noColor {
+			colorMode = "never"
+		}
+		color, err := internal.UseColor(colorMode, os.Stdout)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		reporter, err := internal.NewReporter(format, os.Stdout, internal.ReportOptions{Verbose: verbose, Color: color})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		// Flags given on the command line win over the configuration files
+		override := brokenlinks.Config{}
+		if cmd.Flags().Changed("ext") {
+			override.Extensions = exts
+		}
+		if cmd.Flags().Changed("slug-style") {
+			override.SlugStyle = slugStyle
+		}
+		if cmd.Flags().Changed("check-web") {
+			override.Web.Check = &checkWeb
+		}
+		if cmd.Flags().Changed("web-workers") {
+			override.Web.Workers = webWorkers
+		}
+		if cmd.Flags().Changed("web-timeout") {
+			override.Web.Timeout = webTimeout
+		}
+		override.ExcludePaths = excludePaths
+		override.IgnoreLinks = ignoreLinks
+		configs, err := brokenlinks.LoadConfigs(".", override)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
 		}
-		err := filepath.Walk(directory, f)
+
+		opts := brokenlinks.Options{OnlyErrors: errors_only, IncludeCode: includeCode, NewSlugger: newSlugger, Jobs: jobs, Configs: configs}
+		if check := configs.Base().Web.Check; check != nil && *check {
+			opts.WebChecker = configs.WebChecker()
+		}
+
+		paths, err := brokenlinks.FindFiles(directory, exts, configs)
 
 		if err != nil {
 			fmt.Printf("# Error walking the path %s: %v\n", directory, err)
 			os.Exit(1)
 		}
+
+		// Files are validated concurrently, but reported in walk order
+		summary := brokenlinks.Summary{}
+		failed := 0
+		brokenlinks.ValidateFiles(cmd.Context(), paths, opts, func(res brokenlinks.FileResult) {
+			if err := reporter.File(res); err != nil {
+				fmt.Fprintf(os.Stderr, "# Error writing report: %v\n", err)
+			}
+			summary.Add(res.Summary)
+			if res.Err != nil {
+				failed++
+			}
+		})
+
+		if err := reporter.Finish(summary); err != nil {
+			fmt.Fprintf(os.Stderr, "# Error writing report: %v\n", err)
+			os.Exit(1)
+		}
+		if failed > 0 || summary.BrokenLinks() > 0 {
+			os.Exit(1)
+		}
 	},
 }
 
 var (
-	dir         string
-	ext         string
-	verbose     bool
-	errors_only bool
+	dir          string
+	exts         []string
+	verbose      bool
+	errors_only  bool
+	checkWeb     bool
+	includeCode  bool
+	slugStyle    string
+	format       string
+	colorMode    string
+	noColor      bool
+	excludePaths []string
+	ignoreLinks  []string
+	jobs         int
+	webWorkers   int
+	webTimeout   time.Duration
 )
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -79,9 +147,20 @@ func Execute() {
 
 func init() {
 
-	RootCmd.PersistentFlags().StringVar(&ext, "ext", ".md", "File extension to be filtered on")
+	RootCmd.PersistentFlags().StringSliceVar(&exts, "ext", []string{".md"}, "File extensions to be filtered on, comma separated or repeated; parsed by extension: "+strings.Join(brokenlinks.Extensions(), ", ")+", others as Markdown")
 	RootCmd.PersistentFlags().StringVar(&dir, "dir", "", "Required: directory to be checked")
 	RootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Optional: print file names that are being checked; default: false")
 	RootCmd.PersistentFlags().BoolVar(&errors_only, "errors_only", false, "Optional: print only errors, no weblinks; default: false")
+	RootCmd.PersistentFlags().IntVar(&jobs, "jobs", runtime.NumCPU(), "Optional: number of files validated concurrently")
+	RootCmd.PersistentFlags().BoolVar(&checkWeb, "check-web", false, "Optional: check web links over HTTP instead of printing open commands; default: false")
+	RootCmd.PersistentFlags().BoolVar(&includeCode, "include-code", false, "Optional: also validate links inside code blocks and inline code; default: false")
+	RootCmd.PersistentFlags().StringVar(&slugStyle, "slug-style", "github", "Optional: anchor style of the renderer headings are linked for: "+strings.Join(brokenlinks.SlugStyles(), ", "))
+	RootCmd.PersistentFlags().StringVar(&format, "format", "text", "Optional: report format: "+strings.Join(internal.ReportFormats(), ", "))
+	RootCmd.PersistentFlags().StringVar(&colorMode, "color", "auto", "Optional: color the text report: auto (when writing to a terminal and NO_COLOR is not set), always or never")
+	RootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Optional: same as --color=never; default: false")
+	RootCmd.PersistentFlags().StringArrayVar(&excludePaths, "exclude-path", nil, "Optional, repeatable: glob, or regular expression after re:, of files and directories not to validate")
+	RootCmd.PersistentFlags().StringArrayVar(&ignoreLinks, "ignore-link", nil, "Optional, repeatable: glob, or regular expression after re:, of link targets not to check")
+	RootCmd.PersistentFlags().IntVar(&webWorkers, "web-workers", 8, "Optional: number of concurrent HTTP requests when checking web links")
+	RootCmd.PersistentFlags().DurationVar(&webTimeout, "web-timeout", 10*time.Second, "Optional: timeout per HTTP request when checking web links")
 
 }
@@ -0,0 +1,232 @@
+package internal
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// asciidocParser extracts the links of AsciiDoc documents: the link:, xref:,
+// image: and include:: macros, URLs with a link text and <<id>> cross
+// references. Anchors are the ids Asciidoctor gives section titles, along
+// with the explicit [[id]], [#id] and anchor:id[] anchors.
+//
+// Comments are always skipped. Unless includeCode is set, listing, literal
+// and passthrough blocks and inline literals are skipped too.
+type asciidocParser struct {
+	includeCode bool
+}
+
+var (
+	adocLinkRegex      = regexp.MustCompile(`\blink:(?:\+\+([^+]+)\+\+|([^\s\[]+))\[([^\]]*)\]`)
+	adocURLRegex       = regexp.MustCompile(`(?:^|[^:/\w])(https?://[^\s\[\]<>]+)\[([^\]]*)\]`)
+	adocXrefRegex      = regexp.MustCompile(`\bxref:([^\s\[]+)\[([^\]]*)\]`)
+	adocCrossRefRegex  = regexp.MustCompile(`<<([^,>\s]+)(?:,\s*([^>]*))?>>`)
+	adocImageRegex     = regexp.MustCompile(`\bimage::?([^\s\[]+)\[([^\]]*)\]`)
+	adocIncludeRegex   = regexp.MustCompile(`^include::([^\[]+)\[[^\]]*\]\s*$`)
+	adocTitleRegex     = regexp.MustCompile(`^(={2,6})\s+(\S.*?)\s*$`)
+	adocAnchorRegex    = regexp.MustCompile(`\[\[([A-Za-z_:][-\w:.]*)(?:,[^\]]*)?\]\]|\banchor:([A-Za-z_:][-\w:.]*)\[[^\]]*\]`)
+	adocBlockIDRegex   = regexp.MustCompile(`^\[(?:[\w-]*)#([A-Za-z_:][-\w:]*)[^\]]*\]\s*$`)
+	adocAttributeRegex = regexp.MustCompile(`^:(imagesdir|idprefix|idseparator):\s*(.*?)\s*$`)
+	adocInlineLiteral  = regexp.MustCompile("`[^`]+`|\\+\\+?[^+]+\\+\\+?")
+	adocInvalidIDChars = regexp.MustCompile(`<[^>]+>|&(?:[a-z][a-z]+\d{0,2}|#\d{2,5}|#x[\da-f]{2,4});`)
+)
+
+// adocDelimiter returns the delimiter line of a block that holds no links,
+// or "" when line opens no such block.
+func adocDelimiter(line string, includeCode bool) string {
+	line = strings.TrimRight(line, " \t\r")
+	if len(line) < 4 || strings.Trim(line, line[:1]) != "" {
+		return ""
+	}
+	switch line[0] {
+	case '/':
+		return line
+	case '-', '.', '+':
+		if !includeCode {
+			return line
+		}
+	}
+	return ""
+}
+
+func (p asciidocParser) parse(source []byte, _ Slugger) Document {
+	var doc Document
+	attrs := map[string]string{"idprefix": "_", "idseparator": "_"}
+	seen := map[string]int{}
+	addLink := func(kind LinkKind, text string, target string, line int, column int) {
+		doc.Links = append(doc.Links, Link{Kind: kind, Text: text, Target: target, Line: line, Column: column})
+	}
+	// addRef adds a cross reference to id, file#id or file#
+	addRef := func(target string, text string, line int, column int) {
+		if strings.Contains(target, "{") {
+			// Attributes are not resolved
+			return
+		}
+		file, id, hasID := strings.Cut(target, "#")
+		if !hasID && path.Ext(file) == "" {
+			file, id, hasID = "", file, true
+		}
+		if file != "" && path.Ext(file) == "" {
+			file += ".adoc"
+		}
+		switch {
+		case hasID && id != "":
+			addLink(InternalLink, text, file+"#"+id, line, column)
+		case file != "":
+			addLink(FileLink, text, file, line, column)
+		}
+	}
+
+	delimiter := ""
+	explicitID := false
+	for i, line := range strings.Split(string(source), "\n") {
+		lineNum := i + 1
+		if delimiter != "" {
+			if strings.TrimRight(line, " \t\r") == delimiter {
+				delimiter = ""
+			}
+			continue
+		}
+		if delimiter = adocDelimiter(line, p.includeCode); delimiter != "" {
+			continue
+		}
+		if strings.HasPrefix(line, "//") {
+			continue
+		}
+		if !p.includeCode {
+			line = blankMatches(line, adocInlineLiteral)
+		}
+		column := func(offset int) int {
+			return utf8.RuneCountInString(line[:offset]) + 1
+		}
+
+		if m := adocAttributeRegex.FindStringSubmatch(line); m != nil {
+			attrs[m[1]] = m[2]
+			continue
+		}
+		if m := adocBlockIDRegex.FindStringSubmatch(line); m != nil {
+			doc.Anchors = append(doc.Anchors, m[1])
+			explicitID = true
+			continue
+		}
+		if m := adocTitleRegex.FindStringSubmatch(line); m != nil {
+			// A section with an id of its own gets no generated one
+			if !explicitID {
+				doc.Anchors = append(doc.Anchors, asciidoctorID(m[2], attrs["idprefix"], attrs["idseparator"], seen))
+			}
+		}
+		explicitID = false
+		for _, m := range adocAnchorRegex.FindAllStringSubmatch(line, -1) {
+			doc.Anchors = append(doc.Anchors, m[1]+m[2])
+			if strings.TrimSpace(line) == m[0] {
+				explicitID = true
+			}
+		}
+
+		if m := adocIncludeRegex.FindStringSubmatchIndex(line); m != nil {
+			if target := submatch(line, m, 1); !strings.Contains(target, "{") {
+				if kind, ok := ClassifyLink(target, false); ok {
+					addLink(kind, "", target, lineNum, column(m[0]))
+				}
+			}
+			continue
+		}
+		for _, m := range adocLinkRegex.FindAllStringSubmatchIndex(line, -1) {
+			target := submatch(line, m, 1) + submatch(line, m, 2)
+			if strings.Contains(target, "{") {
+				continue
+			}
+			if kind, ok := ClassifyLink(target, false); ok {
+				addLink(kind, submatch(line, m, 3), target, lineNum, column(m[0]))
+			}
+		}
+		line = blankMatches(line, adocLinkRegex)
+		for _, m := range adocURLRegex.FindAllStringSubmatchIndex(line, -1) {
+			addLink(WebLink, submatch(line, m, 2), submatch(line, m, 1), lineNum, column(m[2]))
+		}
+		for _, m := range adocXrefRegex.FindAllStringSubmatchIndex(line, -1) {
+			addRef(submatch(line, m, 1), submatch(line, m, 2), lineNum, column(m[0]))
+		}
+		for _, m := range adocCrossRefRegex.FindAllStringSubmatchIndex(line, -1) {
+			addRef(submatch(line, m, 1), submatch(line, m, 2), lineNum, column(m[0]))
+		}
+		for _, m := range adocImageRegex.FindAllStringSubmatchIndex(line, -1) {
+			target := submatch(line, m, 1)
+			if strings.Contains(target, "{") {
+				continue
+			}
+			kind, ok := ClassifyLink(target, true)
+			if !ok {
+				continue
+			}
+			if kind == ImageLink && attrs["imagesdir"] != "" && !strings.HasPrefix(target, "/") {
+				if dir := attrs["imagesdir"]; strings.HasPrefix(dir, "http://") || strings.HasPrefix(dir, "https://") {
+					kind, target = WebLink, strings.TrimSuffix(dir, "/")+"/"+target
+				} else {
+					target = path.Join(dir, target)
+				}
+			}
+			addLink(kind, submatch(line, m, 2), target, lineNum, column(m[0]))
+		}
+	}
+	return doc
+}
+
+// asciidoctorID returns the id Asciidoctor generates for a section title:
+// markup and characters other than letters, digits, spaces, hyphens and
+// dots removed, lower case, runs of spaces, hyphens and dots replaced by the
+// separator and the prefix added. Duplicates get a _2, _3, ... suffix. The
+// document title gets no id.
+func asciidoctorID(title string, prefix string, separator string, seen map[string]int) string {
+	id := strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ' || r == '-' || r == '.' || r == '_':
+			return r
+		case unicode.In(r, unicode.L, unicode.M, unicode.N, unicode.Pc):
+			return r
+		default:
+			return -1
+		}
+	}, strings.ToLower(adocInvalidIDChars.ReplaceAllString(title, "")))
+
+	if separator != "" {
+		var b strings.Builder
+		inRun := false
+		for _, r := range id {
+			if r == ' ' || r == '-' || r == '.' {
+				if !inRun {
+					b.WriteString(separator)
+				}
+				inRun = true
+				continue
+			}
+			inRun = false
+			b.WriteRune(r)
+		}
+		id = strings.TrimSuffix(b.String(), separator)
+		if prefix == "" {
+			id = strings.TrimPrefix(id, separator)
+		}
+	}
+	id = prefix + id
+
+	seen[id]++
+	if n := seen[id]; n > 1 {
+		if separator == "" {
+			separator = "_"
+		}
+		for {
+			unique := id + separator + strconv.Itoa(n)
+			if seen[unique] == 0 {
+				seen[unique] = 1
+				return unique
+			}
+			n++
+		}
+	}
+	return id
+}
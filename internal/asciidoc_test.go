@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestAsciidocParser(t *testing.T) {
+	source := "= Title\n" +
+		"\n" +
+		"== First Section\n" +
+		"See <<first_section>>, <<other.adoc#intro,the intro>> and xref:other[other].\n" +
+		"`<<literal>>` is skipped.\n" +
+		"[[explicit,Explicit]]\n" +
+		"== Second\n" +
+		"....\n" +
+		"image::skipped.png[]\n" +
+		"....\n"
+	p := asciidocParser{}
+
+	doc := p.parse([]byte(source), NewGitHubSlugger())
+
+	expectedLinks := []Link{
+		{Kind: InternalLink, Text: "other", Target: "#other", Line: 4, Column: 59},
+		{Kind: InternalLink, Text: "", Target: "#first_section", Line: 4, Column: 5},
+		{Kind: InternalLink, Text: "the intro", Target: "other.adoc#intro", Line: 4, Column: 24},
+	}
+	if !reflect.DeepEqual(doc.Links, expectedLinks) {
+		t.Errorf("Expected links:\n%v\nBut got:\n%v", expectedLinks, doc.Links)
+	}
+	if !reflect.DeepEqual(doc.Anchors, []string{"_first_section", "explicit"}) {
+		t.Errorf("Expected the anchors of the first section and the explicit anchor, but got %v", doc.Anchors)
+	}
+
+	p.includeCode = true
+	if links := p.parse([]byte(source), NewGitHubSlugger()).Links; len(links) != 5 {
+		t.Errorf("Expected 5 links when including code, but got %d: %v", len(links), links)
+	}
+}
+
+func TestAsciidoctorID(t *testing.T) {
+	seen := map[string]int{}
+	tests := []struct {
+		title     string
+		prefix    string
+		separator string
+		expected  string
+	}{
+		{"Getting Started", "_", "_", "_getting_started"},
+		{"Getting Started", "_", "_", "_getting_started_2"},
+		{"What's new in v1.2?", "_", "_", "_whats_new_in_v1_2"},
+		{"Über <em>uns</em> &amp; mehr", "_", "_", "_über_uns_mehr"},
+		{"Plain - Title", "", "-", "plain-title"},
+	}
+	for _, tt := range tests {
+		if id := asciidoctorID(tt.title, tt.prefix, tt.separator, seen); id != tt.expected {
+			t.Errorf("Expected id %q for %q, but got %q", tt.expected, tt.title, id)
+		}
+	}
+}
+
+func TestValidateFileAsciidoc(t *testing.T) {
+	findings, summary, err := validateFile(context.Background(), "../testfiles/asciidoc/index.adoc", ".adoc", Options{OnlyErrors: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFindings(t, findings, []expectedFinding{
+		{6, "#_installation", "broken header link"},
+		{6, "#missing", "broken header link"},
+		{7, "chapters/usage.adoc#nowhere", "broken header link"},
+		{7, "chapters/gone.adoc", "broken file link"},
+		{11, "../img/missing.png", "broken image file link"},
+	})
+	if summary.Links != 9 {
+		t.Errorf("Expected 9 links checked, but got %+v", summary)
+	}
+
+	findings, _, err = validateFile(context.Background(), "../testfiles/asciidoc/chapters/usage.adoc", ".adoc", Options{})
+	if err != nil || len(findings) != 0 {
+		t.Errorf("Expected no findings for the chapter, but got %v (%v)", findings, err)
+	}
+}
@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DocumentCache holds the parsed documents of a run, keyed by absolute
+// path, so every file is read and parsed at most once no matter how many
+// links point into it. It is safe for concurrent use.
+type DocumentCache struct {
+	opts Options
+
+	mu       sync.Mutex
+	docs     map[string]*cachedDocument
+	projects map[string]*cachedLabels
+}
+
+// cachedLabels makes concurrent lookups of the same project share one scan.
+type cachedLabels struct {
+	once   sync.Once
+	labels map[string]bool
+	err    error
+}
+
+// cachedDocument makes concurrent lookups of the same file share one parse.
+type cachedDocument struct {
+	once sync.Once
+	doc  Document
+	err  error
+}
+
+// NewDocumentCache returns an empty cache parsing documents with the
+// parsers and slug style selected by opts and the configuration of their
+// directory.
+func NewDocumentCache(opts Options) *DocumentCache {
+	return &DocumentCache{opts: opts, docs: map[string]*cachedDocument{}, projects: map[string]*cachedLabels{}}
+}
+
+// Get returns the parsed document at path, parsing it on first use.
+// Documents whose extension has no parser are not read; Get returns
+// errNoParser for them.
+func (c *DocumentCache) Get(path string) (Document, error) {
+	parser, ok := parserFor(filepath.Ext(path), c.opts)
+	if !ok {
+		return Document{}, errNoParser
+	}
+	return c.get(path, parser)
+}
+
+// get is Get with parser instead of the parser of the extension of path.
+func (c *DocumentCache) get(path string, parser docParser) (Document, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return Document{}, err
+	}
+
+	c.mu.Lock()
+	entry, ok := c.docs[absPath]
+	if !ok {
+		entry = &cachedDocument{}
+		c.docs[absPath] = entry
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		source, err := os.ReadFile(absPath)
+		if err != nil {
+			entry.err = err
+			return
+		}
+		entry.doc = parser.parse(source, c.opts.sluggerFor(absPath))
+		entry.doc.Suppressions = suppressions(source)
+	})
+	return entry.doc, entry.err
+}
+
+// labels returns the labels defined by the reStructuredText documents in
+// root and its subdirectories, scanning them on first use.
+func (c *DocumentCache) labels(root string) (map[string]bool, error) {
+	c.mu.Lock()
+	entry, ok := c.projects[root]
+	if !ok {
+		entry = &cachedLabels{}
+		c.projects[root] = entry
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		labels := map[string]bool{}
+		entry.err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || filepath.Ext(path) != ".rst" {
+				return err
+			}
+			doc, err := c.Get(path)
+			if err != nil {
+				return err
+			}
+			for _, label := range doc.Labels {
+				labels[label] = true
+			}
+			return nil
+		})
+		entry.labels = labels
+	})
+	return entry.labels, entry.err
+}
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestDocumentCacheParsesOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.md")
+	if err := os.WriteFile(path, []byte("# First\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cache := NewDocumentCache(Options{})
+
+	first, err := cache.Get(path)
+	if err != nil {
+		t.Fatalf("Expected the document to be parsed, but got error: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("# Second\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A relative path to the same file hits the same entry
+	wd, _ := os.Getwd()
+	rel, _ := filepath.Rel(wd, path)
+	second, _ := cache.Get(rel)
+
+	if !reflect.DeepEqual(first.Anchors, []string{"first"}) || !reflect.DeepEqual(second.Anchors, first.Anchors) {
+		t.Errorf("Expected the cached anchors [first] twice, but got %v and %v", first.Anchors, second.Anchors)
+	}
+}
+
+func TestDocumentCacheConcurrentUse(t *testing.T) {
+	cache := NewDocumentCache(Options{})
+
+	var wg sync.WaitGroup
+	results := make([][]string, 16)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			doc, err := cache.Get("../testfiles/subdir/bla.md")
+			if err != nil {
+				t.Errorf("Expected the document to be parsed, but got error: %v", err)
+			}
+			results[i] = doc.Anchors
+		}(i)
+	}
+	wg.Wait()
+
+	for _, anchors := range results {
+		if !reflect.DeepEqual(anchors, results[0]) || len(anchors) != 3 {
+			t.Errorf("Expected the same 3 anchors for every lookup, but got %v", anchors)
+		}
+	}
+}
+
+func TestDocumentCacheMissingFile(t *testing.T) {
+	cache := NewDocumentCache(Options{})
+
+	if _, err := cache.Get("../testfiles/missing.md"); err == nil {
+		t.Errorf("Expected an error for a missing file")
+	}
+}
+
+func TestDocumentCacheNoParser(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewDocumentCache(Options{})
+
+	for _, name := range []string{"spec.pdf", "main.go"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("# Not Markdown\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if doc, err := cache.Get(path); !errors.Is(err, errNoParser) {
+			t.Errorf("Expected %s not to be parsed, but got %v (%v)", name, doc, err)
+		}
+	}
+	if len(cache.docs) != 0 {
+		t.Errorf("Expected nothing to be cached, but got %v", cache.docs)
+	}
+}
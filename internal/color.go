@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// colorModes are the modes accepted by UseColor.
+var colorModes = map[string]bool{"auto": true, "always": true, "never": true}
+
+// ColorModes returns the names of the color modes.
+func ColorModes() []string {
+	var names []string
+	for name := range colorModes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UseColor reports whether output to f should be colored: always, never,
+// or in auto mode only when f is a terminal and NO_COLOR is not set.
+func UseColor(mode string, f *os.File) (bool, error) {
+	switch strings.ToLower(mode) {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+			return false, nil
+		}
+		return isTerminal(f), nil
+	default:
+		return false, fmt.Errorf("unknown color mode %q, expected one of: %s", mode, strings.Join(ColorModes(), ", "))
+	}
+}
+
+// isTerminal reports whether f is a terminal rather than a file or a pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// ANSI escape codes of the colors used by the text report.
+const (
+	colorRed    = "\u001b[31m"
+	colorYellow = "\u001b[33m"
+	colorReset  = "\u001b[0m"
+)
+
+// colorize wraps s in the escape codes of color when enabled.
+func colorize(s string, color string, enabled bool) string {
+	if !enabled {
+		return s
+	}
+	return color + s + colorReset
+}
@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUseColor(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "report.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	t.Setenv("NO_COLOR", "")
+
+	tests := []struct {
+		mode     string
+		expected bool
+	}{
+		{"always", true},
+		{"never", false},
+		// A file is not a terminal
+		{"auto", false},
+	}
+	for _, tt := range tests {
+		if res, err := UseColor(tt.mode, f); err != nil || res != tt.expected {
+			t.Errorf("Expected color %t for mode %s, but got %t (%v)", tt.expected, tt.mode, res, err)
+		}
+	}
+
+	if _, err := UseColor("sometimes", f); err == nil {
+		t.Errorf("Expected an error for an unknown color mode")
+	}
+}
+
+func TestUseColorNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	if res, _ := UseColor("auto", os.Stdout); res {
+		t.Errorf("Expected no color with NO_COLOR set")
+	}
+	if res, _ := UseColor("always", os.Stdout); !res {
+		t.Errorf("Expected --color=always to override NO_COLOR")
+	}
+}
+
+func TestWriteTextPlain(t *testing.T) {
+	var buf bytes.Buffer
+	findings := []Finding{
+		{File: "a.md", Line: 3, Target: "b.md", Severity: SeverityError, Message: "broken file link"},
+		{File: "a.md", Line: 5, Target: "c.md", Severity: SeverityWarning, Message: "unused reference definition"},
+	}
+
+	WriteText(&buf, findings, false)
+
+	expected := "# broken file link in file a.md:3 issue: b.md\n" +
+		"# unused reference definition in file a.md:5 issue: c.md\n"
+	if buf.String() != expected {
+		t.Errorf("Expected output without escape codes:\n%s\nBut got:\n%s", expected, buf.String())
+	}
+}
@@ -0,0 +1,385 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile is the name of the configuration file looked up in the working
+// directory, its parents and the directories that are validated.
+const ConfigFile = ".brokenlinks.yaml"
+
+// Config is the content of a configuration file. Fields that are left out
+// keep the value of the parent directory's configuration.
+type Config struct {
+	// Extensions are the extensions of the files to validate.
+	Extensions []string `yaml:"extensions"`
+	// Kinds are the kinds of links to check. Empty means all kinds.
+	Kinds []LinkKind `yaml:"kinds"`
+	// SlugStyle is the anchor style of the headings, see SluggerFor.
+	SlugStyle string `yaml:"slug-style"`
+	// Web controls checking web links over HTTP.
+	Web WebConfig `yaml:"web"`
+	// ExcludePaths are patterns of files and directories that are not
+	// validated, see Excludes.
+	ExcludePaths []string `yaml:"exclude-paths"`
+	// IgnoreLinks are patterns of link targets that are not checked, see
+	// compilePattern.
+	IgnoreLinks []string `yaml:"ignore-links"`
+	// Severity overrides the severity of broken links per kind.
+	Severity map[LinkKind]Severity `yaml:"severity"`
+
+	excludePaths []pathPattern
+	ignoreLinks  []*regexp.Regexp
+}
+
+// pathPattern is a compiled pattern of ExcludePaths. Patterns of a
+// configuration file match paths relative to dir, the directory of the
+// file; those of the override, with no dir, match paths relative to the
+// root of the run.
+type pathPattern struct {
+	re  *regexp.Regexp
+	dir string
+}
+
+// WebConfig controls checking web links over HTTP. Workers and Timeout are
+// taken from the configuration of the working directory only, as all web
+// links of a run share one checker.
+type WebConfig struct {
+	Check   *bool         `yaml:"check"`
+	Workers int           `yaml:"workers"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// ParseConfig parses the content of a configuration file. Unknown keys
+// are errors, so typos do not go unnoticed.
+func ParseConfig(data []byte) (Config, error) {
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, err
+	}
+	if err := cfg.compile(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// compile checks the settings that can be wrong and compiles the patterns.
+func (c *Config) compile() error {
+	if c.SlugStyle != "" {
+		if _, err := SluggerFor(c.SlugStyle); err != nil {
+			return err
+		}
+	}
+	for _, ext := range c.Extensions {
+		if !strings.HasPrefix(ext, ".") {
+			return fmt.Errorf("extension %q must start with a dot", ext)
+		}
+	}
+	for kind, severity := range c.Severity {
+		// An info finding reads like an unchecked web link and does not
+		// fail the run, which would hide broken links
+		if severity != SeverityError && severity != SeverityWarning {
+			return fmt.Errorf("severity of %s links must be error or warning, not %s", kind, severity)
+		}
+	}
+	excludePaths, err := compilePatterns("exclude-paths", c.ExcludePaths)
+	if err != nil {
+		return err
+	}
+	c.excludePaths = nil
+	for _, re := range excludePaths {
+		c.excludePaths = append(c.excludePaths, pathPattern{re: re})
+	}
+	c.ignoreLinks, err = compilePatterns("ignore-links", c.IgnoreLinks)
+	return err
+}
+
+// anchorPatterns makes the exclude patterns of the configuration file in
+// dir match paths relative to dir.
+func (c *Config) anchorPatterns(dir string) {
+	for i := range c.excludePaths {
+		c.excludePaths[i].dir = dir
+	}
+}
+
+// merge returns the configuration of a subdirectory: c overridden by the
+// settings of child. Lists replace the parent's list, severities are
+// overridden per kind.
+func (c Config) merge(child Config) Config {
+	merged := c
+	if child.Extensions != nil {
+		merged.Extensions = child.Extensions
+	}
+	if child.Kinds != nil {
+		merged.Kinds = child.Kinds
+	}
+	if child.SlugStyle != "" {
+		merged.SlugStyle = child.SlugStyle
+	}
+	if child.Web.Check != nil {
+		merged.Web.Check = child.Web.Check
+	}
+	if child.Web.Workers != 0 {
+		merged.Web.Workers = child.Web.Workers
+	}
+	if child.Web.Timeout != 0 {
+		merged.Web.Timeout = child.Web.Timeout
+	}
+	if child.ExcludePaths != nil {
+		merged.ExcludePaths = child.ExcludePaths
+		merged.excludePaths = child.excludePaths
+	}
+	if child.IgnoreLinks != nil {
+		merged.IgnoreLinks = child.IgnoreLinks
+		merged.ignoreLinks = child.ignoreLinks
+	}
+	if child.Severity != nil {
+		merged.Severity = map[LinkKind]Severity{}
+		for kind, severity := range c.Severity {
+			merged.Severity[kind] = severity
+		}
+		for kind, severity := range child.Severity {
+			merged.Severity[kind] = severity
+		}
+	}
+	return merged
+}
+
+// checks reports whether links of kind are checked.
+func (c Config) checks(kind LinkKind) bool {
+	if len(c.Kinds) == 0 {
+		return true
+	}
+	for _, k := range c.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// filterLinks drops the links that are not checked.
+func (c Config) filterLinks(links []Link) []Link {
+	var res []Link
+	for _, link := range links {
+		if c.checks(link.Kind) && !c.ignores(link.Target) {
+			res = append(res, link)
+		}
+	}
+	return res
+}
+
+// applySeverity overrides the severity of broken links. Links that are no
+// longer errors are dropped with onlyErrors.
+func (c Config) applySeverity(findings []Finding, onlyErrors bool) []Finding {
+	if len(c.Severity) == 0 {
+		return findings
+	}
+	var res []Finding
+	for _, f := range findings {
+		if severity, ok := c.Severity[f.Kind]; ok && f.Severity == SeverityError {
+			f.Severity = severity
+		}
+		if onlyErrors && f.Severity != SeverityError {
+			continue
+		}
+		res = append(res, f)
+	}
+	return res
+}
+
+// Configs finds the configuration of every directory of a run. The
+// configuration files of the working directory and its parents apply
+// everywhere, the innermost one winning; a configuration file in any other
+// directory applies to that directory and its subdirectories. The override,
+// typically built from command line flags, wins over all files. Configs is
+// safe for concurrent use.
+type Configs struct {
+	workDir  string
+	base     Config
+	override Config
+
+	mu   sync.Mutex
+	dirs map[string]*cachedConfig
+
+	webOnce sync.Once
+	web     *WebChecker
+}
+
+// cachedConfig makes concurrent lookups of the same directory share one
+// read of its configuration file.
+type cachedConfig struct {
+	once sync.Once
+	cfg  Config
+	err  error
+}
+
+// LoadConfigs reads the configuration files of workDir and its parents.
+func LoadConfigs(workDir string, override Config) (*Configs, error) {
+	absDir, err := filepath.Abs(workDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := override.compile(); err != nil {
+		return nil, err
+	}
+	c := &Configs{workDir: absDir, override: override, dirs: map[string]*cachedConfig{}}
+
+	var chain []string
+	for dir := absDir; ; dir = filepath.Dir(dir) {
+		chain = append(chain, dir)
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	// Apply the outermost configuration first
+	for i := len(chain) - 1; i >= 0; i-- {
+		cfg, err := readConfig(chain[i])
+		if err != nil {
+			return nil, err
+		}
+		cfg.anchorPatterns(chain[i])
+		c.base = c.base.merge(cfg)
+	}
+	return c, nil
+}
+
+// Base returns the configuration of the working directory.
+func (c *Configs) Base() Config {
+	if c == nil {
+		return Config{}
+	}
+	return c.withOverride(c.base)
+}
+
+// For returns the configuration of the files in dir. A nil Configs has
+// an empty configuration everywhere.
+func (c *Configs) For(dir string) (Config, error) {
+	if c == nil {
+		return Config{}, nil
+	}
+	cfg, err := c.resolve(dir)
+	if err != nil {
+		return Config{}, err
+	}
+	return c.withOverride(cfg), nil
+}
+
+// withOverride applies the override to cfg. Unlike the settings of nested
+// files, the patterns of the override add to the patterns of cfg.
+func (c *Configs) withOverride(cfg Config) Config {
+	merged := cfg.merge(c.override)
+	if c.override.ExcludePaths != nil {
+		merged.ExcludePaths = append(cfg.ExcludePaths[:len(cfg.ExcludePaths):len(cfg.ExcludePaths)], c.override.ExcludePaths...)
+		merged.excludePaths = append(cfg.excludePaths[:len(cfg.excludePaths):len(cfg.excludePaths)], c.override.excludePaths...)
+	}
+	if c.override.IgnoreLinks != nil {
+		merged.IgnoreLinks = append(cfg.IgnoreLinks[:len(cfg.IgnoreLinks):len(cfg.IgnoreLinks)], c.override.IgnoreLinks...)
+		merged.ignoreLinks = append(cfg.ignoreLinks[:len(cfg.ignoreLinks):len(cfg.ignoreLinks)], c.override.ignoreLinks...)
+	}
+	return merged
+}
+
+// resolve returns the configuration of dir without the override.
+func (c *Configs) resolve(dir string) (Config, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return Config{}, err
+	}
+	if isAncestor(absDir, c.workDir) {
+		return c.base, nil
+	}
+
+	c.mu.Lock()
+	entry, ok := c.dirs[absDir]
+	if !ok {
+		entry = &cachedConfig{}
+		c.dirs[absDir] = entry
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		parent, err := c.resolve(filepath.Dir(absDir))
+		if err != nil {
+			entry.err = err
+			return
+		}
+		cfg, err := readConfig(absDir)
+		if err != nil {
+			entry.err = err
+			return
+		}
+		cfg.anchorPatterns(absDir)
+		entry.cfg = parent.merge(cfg)
+	})
+	return entry.cfg, entry.err
+}
+
+// WebChecker returns the checker shared by all files that check web links,
+// set up with the web settings of the working directory.
+func (c *Configs) WebChecker() *WebChecker {
+	c.webOnce.Do(func() {
+		web := c.Base().Web
+		workers, timeout := web.Workers, web.Timeout
+		if workers == 0 {
+			workers = 8
+		}
+		if timeout == 0 {
+			timeout = 10 * time.Second
+		}
+		c.web = NewWebChecker(&http.Client{}, workers, timeout)
+	})
+	return c.web
+}
+
+// webChecker returns the checker for files with configuration cfg, given
+// the checker of the run.
+func (c *Configs) webChecker(cfg Config, checker *WebChecker) *WebChecker {
+	switch {
+	case cfg.Web.Check == nil:
+		return checker
+	case !*cfg.Web.Check:
+		return nil
+	case checker != nil:
+		return checker
+	default:
+		return c.WebChecker()
+	}
+}
+
+// readConfig reads the configuration file in dir, if there is one.
+func readConfig(dir string) (Config, error) {
+	path := filepath.Join(dir, ConfigFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Config{}, nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	cfg, err := ParseConfig(data)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// isAncestor reports whether dir is path or one of its parents.
+func isAncestor(dir string, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,199 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseConfig(t *testing.T) {
+	data := []byte(`
+extensions: [.md, .rst]
+kinds: [file, internal]
+slug-style: gitlab
+web:
+  check: true
+  workers: 4
+  timeout: 5s
+ignore-links:
+  - "https://intranet.example.com/**"
+severity:
+  web: warning
+`)
+
+	cfg, err := ParseConfig(data)
+	if err != nil {
+		t.Fatalf("Expected the config to parse, but got error: %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.Extensions, []string{".md", ".rst"}) || !reflect.DeepEqual(cfg.Kinds, []LinkKind{FileLink, InternalLink}) {
+		t.Errorf("Expected extensions and kinds, but got %v and %v", cfg.Extensions, cfg.Kinds)
+	}
+	if cfg.SlugStyle != "gitlab" || cfg.Web.Check == nil || !*cfg.Web.Check || cfg.Web.Workers != 4 || cfg.Web.Timeout != 5*time.Second {
+		t.Errorf("Expected slug style and web settings, but got %+v", cfg)
+	}
+	if !cfg.ignores("https://intranet.example.com/a/b") || cfg.ignores("https://example.com/") {
+		t.Errorf("Expected only intranet links to be ignored")
+	}
+	if cfg.Severity[WebLink] != SeverityWarning {
+		t.Errorf("Expected web links to be warnings, but got %v", cfg.Severity)
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	tests := []string{
+		"extension: [.md]",
+		"kinds: [files]",
+		"slug-style: confluence",
+		"severity:\n  web: fatal",
+		"severity:\n  file: info",
+		"extensions: [md]",
+	}
+	for _, data := range tests {
+		if _, err := ParseConfig([]byte(data)); err == nil {
+			t.Errorf("Expected an error for config %q", data)
+		}
+	}
+}
+
+// writeConfig writes a configuration file into dir.
+func writeConfig(t *testing.T, dir string, data string) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ConfigFile), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigsNested(t *testing.T) {
+	root := t.TempDir()
+	work := filepath.Join(root, "work")
+	writeConfig(t, root, "slug-style: gitlab\nseverity:\n  web: warning\n")
+	writeConfig(t, work, "kinds: [file, web]\n")
+	writeConfig(t, filepath.Join(work, "docs", "api"), "slug-style: mkdocs\nseverity:\n  file: warning\n")
+
+	configs, err := LoadConfigs(work, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	docs, _ := configs.For(filepath.Join(work, "docs"))
+	if docs.SlugStyle != "gitlab" || !reflect.DeepEqual(docs.Kinds, []LinkKind{FileLink, WebLink}) {
+		t.Errorf("Expected the working directory's config in docs, but got %+v", docs)
+	}
+
+	api, _ := configs.For(filepath.Join(work, "docs", "api", "v1"))
+	expectedSeverity := map[LinkKind]Severity{WebLink: SeverityWarning, FileLink: SeverityWarning}
+	if api.SlugStyle != "mkdocs" || !reflect.DeepEqual(api.Severity, expectedSeverity) || len(api.Kinds) != 2 {
+		t.Errorf("Expected api to override the slug style and add a severity, but got %+v", api)
+	}
+
+	// The working directory's config applies outside of it as well
+	other, _ := configs.For(filepath.Join(root, "other"))
+	if !reflect.DeepEqual(other.Kinds, []LinkKind{FileLink, WebLink}) {
+		t.Errorf("Expected the working directory's config outside of it, but got %+v", other)
+	}
+
+	overridden, _ := LoadConfigs(work, Config{SlugStyle: "hugo"})
+	api, _ = overridden.For(filepath.Join(work, "docs", "api"))
+	if api.SlugStyle != "hugo" {
+		t.Errorf("Expected the override to win, but got slug style %s", api.SlugStyle)
+	}
+}
+
+func TestConfigsInvalidFile(t *testing.T) {
+	root := t.TempDir()
+	writeConfig(t, filepath.Join(root, "docs"), "kinds: [nothing]\n")
+
+	configs, err := LoadConfigs(root, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := configs.For(filepath.Join(root, "docs")); err == nil {
+		t.Errorf("Expected an error for an invalid config file")
+	}
+}
+
+func TestValidateFileConfig(t *testing.T) {
+	root := t.TempDir()
+	doc := "[missing](missing.md) ![image](missing.png) [generated](gen/api.md) [heading](#nowhere)\n"
+	if err := os.WriteFile(filepath.Join(root, "index.md"), []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeConfig(t, root, "kinds: [file, image]\nignore-links: [\"gen/**\"]\nseverity:\n  image: warning\n")
+
+	configs, err := LoadConfigs(root, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings, summary, err := validateFile(context.Background(), filepath.Join(root, "index.md"), ".md", Options{Configs: configs})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(findings) != 2 || findings[0].Target != "missing.md" || findings[1].Severity != SeverityWarning {
+		t.Errorf("Expected a broken file link and an image warning, but got %v", findings)
+	}
+	if summary.Links != 2 || summary.BrokenLinks() != 1 {
+		t.Errorf("Expected 2 links checked and 1 broken, but got %+v", summary)
+	}
+
+	findings, _, _ = validateFile(context.Background(), filepath.Join(root, "index.md"), ".md", Options{Configs: configs, OnlyErrors: true})
+	if len(findings) != 1 {
+		t.Errorf("Expected the image warning to be dropped with OnlyErrors, but got %v", findings)
+	}
+}
+
+func TestValidateFileConfigSlugStyle(t *testing.T) {
+	root := t.TempDir()
+	writeConfig(t, filepath.Join(root, "d"), "slug-style: mkdocs\n")
+	doc := "# Über uns\n\n[x](#uber-uns)\n"
+	if err := os.WriteFile(filepath.Join(root, "d", "a.md"), []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	configs, err := LoadConfigs(root, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings, _, err := validateFile(context.Background(), filepath.Join(root, "d", "a.md"), ".md", Options{Configs: configs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("Expected the heading to be slugged in the mkdocs style of the nested config, but got %v", findings)
+	}
+}
+
+func TestConfigSeverityInfo(t *testing.T) {
+	// An info finding would be printed like an unchecked web link and pass
+	// the run, so the config is rejected, which fails the run instead
+	root := t.TempDir()
+	writeConfig(t, root, "severity:\n  file: info\n")
+	if _, err := LoadConfigs(root, Config{}); err == nil || !strings.Contains(err.Error(), "must be error or warning") {
+		t.Errorf("Expected the working directory's config to be rejected, but got %v", err)
+	}
+
+	work := t.TempDir()
+	docs := filepath.Join(work, "docs")
+	writeConfig(t, docs, "severity:\n  file: info\n")
+	if err := os.WriteFile(filepath.Join(docs, "a.md"), []byte("[x](missing.md)\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	configs, err := LoadConfigs(work, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FindFiles(work, []string{".md"}, configs); err == nil {
+		t.Errorf("Expected the nested config to be rejected")
+	}
+	_, _, err = validateFile(context.Background(), filepath.Join(docs, "a.md"), ".md", Options{Configs: configs})
+	if err == nil || !strings.Contains(err.Error(), filepath.Join(docs, ConfigFile)) {
+		t.Errorf("Expected a.md to fail with the error of its config, but got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Severity ranks a finding.
+type Severity int
+
+const (
+	// SeverityError is a broken link.
+	SeverityError Severity = iota
+	// SeverityWarning is a problem that does not break a link, like an
+	// unused reference definition.
+	SeverityWarning
+	// SeverityInfo lists a web link that was not checked.
+	SeverityInfo
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// MarshalText encodes the severity by its name, like "error".
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a severity from its name.
+func (s *Severity) UnmarshalText(text []byte) error {
+	for _, severity := range []Severity{SeverityError, SeverityWarning, SeverityInfo} {
+		if severity.String() == string(text) {
+			*s = severity
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown severity %q", text)
+}
+
+// Finding is a single result of validating a file, with the 1-based line
+// and column of the link it is about.
+type Finding struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Text     string   `json:"text"`
+	Target   string   `json:"target"`
+	Kind     LinkKind `json:"kind"`
+	Severity Severity `json:"severity"`
+	// Message describes the finding, like "broken file link".
+	Message string `json:"message"`
+	// Detail explains the finding further when there is more to say, like
+	// the HTTP status of a broken web link.
+	Detail string `json:"detail,omitempty"`
+}
+
+// newFinding returns a finding about link in the file at filePath.
+func newFinding(link Link, filePath string, severity Severity, message string) Finding {
+	return Finding{
+		File:     filePath,
+		Line:     link.Line,
+		Column:   link.Column,
+		Text:     link.Text,
+		Target:   link.Target,
+		Kind:     link.Kind,
+		Severity: severity,
+		Message:  message,
+	}
+}
+
+// String formats the finding like "# broken file link in file a.md:3 issue:
+// b.md", without colors.
+func (f Finding) String() string {
+	issue := f.Target
+	if f.Detail != "" {
+		issue += " (" + f.Detail + ")"
+	}
+	return fmt.Sprintf("# %s in file %s:%d issue: %s", f.Message, f.File, f.Line, issue)
+}
+
+// sortFindings orders findings by their position in the file.
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+		return findings[i].Column < findings[j].Column
+	})
+}
+
+// WriteText writes findings as the classic text report: errors,
+// warnings and unchecked web links as `open` commands. With color, errors
+// are red and warnings yellow.
+func WriteText(w io.Writer, findings []Finding, color bool) {
+	for _, f := range findings {
+		switch f.Severity {
+		case SeverityError:
+			fmt.Fprintln(w, colorize(f.String(), colorRed, color))
+		case SeverityWarning:
+			fmt.Fprintln(w, colorize(f.String(), colorYellow, color))
+		default:
+			fmt.Fprintf(w, "open %s # filepath: %s:%d\n", f.Target, f.File, f.Line)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// githubReporter writes GitHub Actions workflow commands, which annotate
+// the lines of broken links in pull request diffs. Unchecked web links are
+// left out; the summary ends the log as plain text.
+type githubReporter struct {
+	w io.Writer
+}
+
+func (r *githubReporter) File(res FileResult) error {
+	if res.Err != nil {
+		fmt.Fprintf(r.w, "::error file=%s::%s\n", githubProperty(res.Path), githubData(res.Err.Error()))
+	}
+	for _, f := range res.Findings {
+		command := "error"
+		switch f.Severity {
+		case SeverityInfo:
+			continue
+		case SeverityWarning:
+			command = "warning"
+		}
+		message := f.Message + " " + f.Target
+		if f.Detail != "" {
+			message += " (" + f.Detail + ")"
+		}
+		fmt.Fprintf(r.w, "::%s file=%s,line=%d,col=%d,title=%s::%s\n",
+			command, githubProperty(f.File), f.Line, f.Column, githubProperty(f.Message), githubData(message))
+	}
+	return nil
+}
+
+func (r *githubReporter) Finish(summary Summary) error {
+	summary.Write(r.w)
+	return nil
+}
+
+// githubData escapes the message of a workflow command.
+var githubData = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace
+
+// githubProperty escapes a property value of a workflow command, which
+// also cannot contain the separators of the properties.
+var githubProperty = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace
@@ -0,0 +1,163 @@
+package internal
+
+import (
+	"bytes"
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlParser extracts the links of HTML documents from a href, area href,
+// img src and srcset, link href, script src and source src and srcset.
+// Targets are resolved against <base href>. Anchors are the ids of all
+// elements and the names of a elements.
+//
+// Root relative targets like /css/site.css are skipped, as the root of the
+// site is not known.
+type htmlParser struct{}
+
+// htmlLinkAttrs lists the attributes holding links per element, and
+// whether they point at images.
+var htmlLinkAttrs = map[atom.Atom][]struct {
+	name  string
+	image bool
+}{
+	atom.A:      {{"href", false}},
+	atom.Area:   {{"href", false}},
+	atom.Img:    {{"src", true}, {"srcset", true}},
+	atom.Link:   {{"href", false}},
+	atom.Script: {{"src", false}},
+	atom.Source: {{"src", true}, {"srcset", true}},
+}
+
+func (p htmlParser) parse(source []byte, _ Slugger) Document {
+	var doc Document
+	idx := newLineIndex(source)
+	var base *url.URL
+	// text collects the text of the a element being parsed
+	text, inAnchor := "", -1
+
+	z := html.NewTokenizer(bytes.NewReader(source))
+	offset := 0
+	for {
+		tt := z.Next()
+		start := offset
+		offset += len(z.Raw())
+		switch tt {
+		case html.ErrorToken:
+			return doc
+		case html.TextToken:
+			if inAnchor >= 0 {
+				text += string(z.Text())
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); atom.Lookup(name) == atom.A && inAnchor >= 0 {
+				doc.Links[inAnchor].Text = strings.Join(strings.Fields(text), " ")
+				text, inAnchor = "", -1
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			attrs := map[string]string{}
+			for _, attr := range token.Attr {
+				attrs[attr.Key] = attr.Val
+			}
+			if id := attrs["id"]; id != "" {
+				doc.Anchors = append(doc.Anchors, id)
+			}
+			if name := attrs["name"]; name != "" && token.DataAtom == atom.A {
+				doc.Anchors = append(doc.Anchors, name)
+			}
+			if token.DataAtom == atom.Base && base == nil {
+				if href, ok := attrs["href"]; ok {
+					base, _ = url.Parse(strings.TrimSpace(href))
+				}
+				continue
+			}
+			if token.DataAtom == atom.Link && isResourceHint(attrs["rel"]) {
+				continue
+			}
+
+			line, column := idx.position(start)
+			for _, attr := range htmlLinkAttrs[token.DataAtom] {
+				value, ok := attrs[attr.name]
+				if !ok {
+					continue
+				}
+				targets := []string{value}
+				if attr.name == "srcset" {
+					targets = srcsetURLs(value)
+				}
+				for _, target := range targets {
+					kind, target, ok := resolveHTMLTarget(base, strings.TrimSpace(target), attr.image)
+					if !ok {
+						continue
+					}
+					if token.DataAtom == atom.A && tt == html.StartTagToken {
+						inAnchor = len(doc.Links)
+					}
+					doc.Links = append(doc.Links, Link{Kind: kind, Text: attrs["alt"], Target: target, Line: line, Column: column})
+				}
+			}
+		}
+	}
+}
+
+// isResourceHint reports whether the rel attribute of a link element makes
+// it a hint to connect to a host, which is no document.
+func isResourceHint(rel string) bool {
+	for _, r := range strings.Fields(strings.ToLower(rel)) {
+		if r == "preconnect" || r == "dns-prefetch" {
+			return true
+		}
+	}
+	return false
+}
+
+// srcsetURLs returns the URLs of a srcset attribute, dropping their width
+// and density descriptors.
+func srcsetURLs(srcset string) []string {
+	var urls []string
+	for _, candidate := range strings.Split(srcset, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			urls = append(urls, fields[0])
+		}
+	}
+	return urls
+}
+
+// resolveHTMLTarget resolves target against the base URL, when there is
+// one, and returns the kind of link and the target to check. Local targets
+// lose their query and are unescaped, so they name a file.
+func resolveHTMLTarget(base *url.URL, target string, image bool) (LinkKind, string, bool) {
+	if strings.HasPrefix(target, "//") {
+		target = "https:" + target
+	}
+	if base != nil && base.String() != "" {
+		if ref, err := url.Parse(target); err == nil {
+			switch {
+			case base.IsAbs():
+				target = base.ResolveReference(ref).String()
+			case ref.IsAbs() || strings.HasPrefix(target, "/") || strings.HasPrefix(target, "#"):
+			default:
+				dir := base.Path
+				if !strings.HasSuffix(dir, "/") {
+					dir = path.Dir(dir)
+				}
+				ref.Path = path.Join(dir, ref.Path)
+				target = ref.String()
+			}
+		}
+	}
+
+	kind, ok := ClassifyLink(target, image)
+	if !ok || kind == WebLink {
+		return kind, target, ok
+	}
+	if strings.HasPrefix(target, "/") {
+		return 0, "", false
+	}
+	return localTarget(kind, target, image)
+}
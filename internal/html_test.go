@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestHTMLParser(t *testing.T) {
+	source := "<p id=\"intro\">See <a href=\"other.html?x=1#Some%20Part\">the <b>other</b>\n" +
+		"page</a> and <img src=\"a.png\" srcset=\"b.png 2x, //cdn.example.com/c.png 3x\" alt=\"A\"></p>\n" +
+		"<a href=\"javascript:void(0)\">Nothing</a> <a href=\"/root.html\">Root</a> <a name=\"end\" href=\"#intro\">Up</a>\n"
+
+	doc := htmlParser{}.parse([]byte(source), NewGitHubSlugger())
+
+	expectedLinks := []Link{
+		{Kind: InternalLink, Text: "the other page", Target: "other.html#Some%20Part", Line: 1, Column: 19},
+		{Kind: ImageLink, Text: "A", Target: "a.png", Line: 2, Column: 14},
+		{Kind: ImageLink, Text: "A", Target: "b.png", Line: 2, Column: 14},
+		{Kind: WebLink, Text: "A", Target: "https://cdn.example.com/c.png", Line: 2, Column: 14},
+		{Kind: InternalLink, Text: "Up", Target: "#intro", Line: 3, Column: 72},
+	}
+	if !reflect.DeepEqual(doc.Links, expectedLinks) {
+		t.Errorf("Expected links:\n%v\nBut got:\n%v", expectedLinks, doc.Links)
+	}
+	if !reflect.DeepEqual(doc.Anchors, []string{"intro", "end"}) {
+		t.Errorf("Expected the id and name anchors, but got %v", doc.Anchors)
+	}
+}
+
+func TestHTMLParserBase(t *testing.T) {
+	tests := []struct {
+		base     string
+		href     string
+		expected Link
+	}{
+		{"https://example.com/docs/", "page.html", Link{Kind: WebLink, Target: "https://example.com/docs/page.html"}},
+		{"https://example.com/docs/", "#part", Link{Kind: WebLink, Target: "https://example.com/docs/#part"}},
+		{"../", "page.html#part", Link{Kind: InternalLink, Target: "../page.html#part"}},
+		{"sub/index.html", "page.html", Link{Kind: FileLink, Target: "sub/page.html"}},
+		{"sub/", "#part", Link{Kind: InternalLink, Target: "#part"}},
+	}
+	for _, tt := range tests {
+		source := "<base href=\"" + tt.base + "\"><a href=\"" + tt.href + "\"></a>"
+		links := htmlParser{}.parse([]byte(source), NewGitHubSlugger()).Links
+		if len(links) != 1 || links[0].Kind != tt.expected.Kind || links[0].Target != tt.expected.Target {
+			t.Errorf("Expected %s link %s for %s with base %s, but got %v", tt.expected.Kind, tt.expected.Target, tt.href, tt.base, links)
+		}
+	}
+}
+
+func TestValidateFileHTML(t *testing.T) {
+	findings, summary, err := validateFile(context.Background(), "../testfiles/site/index.html", ".html", Options{OnlyErrors: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFindings(t, findings, []expectedFinding{
+		{5, "css/missing.css", "broken file link"},
+		{7, "js/missing.js", "broken file link"},
+		{11, "docs/page.html#nowhere", "broken header link"},
+		{12, "#bottom", "broken header link"},
+		{13, "../img/missing.png", "broken image file link"},
+	})
+	if summary.BrokenLinks() != 5 {
+		t.Errorf("Expected 5 broken links, but got %+v", summary)
+	}
+
+	findings, _, err = validateFile(context.Background(), "../testfiles/site/docs/page.html", ".html", Options{})
+	if err != nil || len(findings) != 0 {
+		t.Errorf("Expected the links of the page to resolve against its base, but got %v (%v)", findings, err)
+	}
+}
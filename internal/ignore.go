@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// compilePattern compiles a pattern of ExcludePaths or IgnoreLinks. A
+// pattern is a glob matching the whole text, where * matches any text but
+// a /, ** matches any text and ? a single character. With a "re:" prefix
+// it is a regular expression matching any part of the text instead.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+		return regexp.Compile(expr)
+	}
+	return globRegexp(pattern)
+}
+
+// compilePatterns compiles the patterns of a setting.
+func compilePatterns(setting string, patterns []string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := compilePattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern %q: %v", setting, pattern, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// globRegexp compiles a glob pattern matching a whole text.
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				sb.WriteString(".*")
+				i++
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// ignores reports whether a link target matches an ignore pattern.
+func (c Config) ignores(target string) bool {
+	for _, re := range c.ignoreLinks {
+		if re.MatchString(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Excludes reports whether the file or directory at rel, a slash separated
+// path relative to root, the root of the run, is excluded. Glob patterns
+// without a / match the name of any file or directory, like in .gitignore;
+// other patterns match the whole path relative to the directory of the
+// configuration file they come from, see pathPattern.
+func (c Config) Excludes(root string, rel string) bool {
+	name := rel[strings.LastIndex(rel, "/")+1:]
+	for i, p := range c.excludePaths {
+		pattern := c.ExcludePaths[i]
+		if !strings.HasPrefix(pattern, "re:") && !strings.Contains(pattern, "/") {
+			if p.re.MatchString(name) {
+				return true
+			}
+			continue
+		}
+		path := rel
+		if p.dir != "" {
+			abs, err := filepath.Abs(filepath.Join(root, filepath.FromSlash(rel)))
+			if err != nil || !isAncestor(p.dir, abs) {
+				continue
+			}
+			path, _ = filepath.Rel(p.dir, abs)
+			path = filepath.ToSlash(path)
+		}
+		if p.re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindFiles returns the files below root to validate: those with one of the
+// configured extensions, or one of extensions where none are configured,
+// that are not excluded.
+func FindFiles(root string, extensions []string, configs *Configs) ([]string, error) {
+	var paths []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// A directory is excluded by the configuration of its parent
+		cfg, err := configs.For(filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		if path != root {
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			if cfg.Excludes(root, filepath.ToSlash(rel)) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		exts := cfg.Extensions
+		if len(exts) == 0 {
+			exts = extensions
+		}
+		for _, ext := range exts {
+			if strings.EqualFold(filepath.Ext(path), ext) {
+				paths = append(paths, path)
+				break
+			}
+		}
+		return nil
+	})
+	return paths, err
+}
@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGlobRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		target  string
+		matches bool
+	}{
+		{"*.md", "a.md", true},
+		{"*.md", "docs/a.md", false},
+		{"**.md", "docs/a.md", true},
+		{"docs/?.md", "docs/a.md", true},
+		{"https://example.com/*", "https://example.com/a", true},
+		{"https://example.com/*", "https://example.com/a/b", false},
+		{"https://example.com/**", "https://example.com/a/b", true},
+		{"a+b.md", "a+b.md", true},
+	}
+	for _, tt := range tests {
+		re, err := globRegexp(tt.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res := re.MatchString(tt.target); res != tt.matches {
+			t.Errorf("Expected %s to match %s: %t, but got %t", tt.pattern, tt.target, tt.matches, res)
+		}
+	}
+}
+
+func TestCompilePatternRegexp(t *testing.T) {
+	re, err := compilePattern(`re:^https?://(www\.)?internal\.`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !re.MatchString("https://internal.example.com/a") || re.MatchString("https://example.com/internal.html") {
+		t.Errorf("Expected the regular expression to match internal hosts only")
+	}
+
+	if _, err := compilePattern("re:("); err == nil {
+		t.Errorf("Expected an error for an invalid regular expression")
+	}
+}
+
+func TestExcludes(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`exclude-paths: ["vendor", "docs/generated/**", "*.draft.md", "re:/tmp[0-9]+/"]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		rel      string
+		excluded bool
+	}{
+		{"vendor", true},
+		{"lib/vendor", true},
+		{"vendors", false},
+		{"docs/generated/api/index.md", true},
+		{"generated/index.md", false},
+		{"notes/todo.draft.md", true},
+		{"build/tmp12/out.md", true},
+		{"docs/index.md", false},
+	}
+	for _, tt := range tests {
+		if res := cfg.Excludes(".", tt.rel); res != tt.excluded {
+			t.Errorf("Expected %s to be excluded: %t, but got %t", tt.rel, tt.excluded, res)
+		}
+	}
+}
+
+func TestFindFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, file := range []string{"index.md", "notes.txt", "vendor/lib/README.md", "docs/a.md", "docs/gen/b.md", "docs/c.rst", "guide/gen/x.md", "guide/y.md"} {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig(t, filepath.Join(root, "docs"), "extensions: [.md, .rst]\nexclude-paths: [gen]\n")
+	// Patterns with a / are relative to the directory of their file
+	writeConfig(t, filepath.Join(root, "guide"), "exclude-paths: [gen/**]\n")
+
+	configs, err := LoadConfigs(root, Config{ExcludePaths: []string{"vendor"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths, err := FindFiles(root, []string{".md"}, configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rels []string
+	for _, path := range paths {
+		rel, _ := filepath.Rel(root, path)
+		rels = append(rels, filepath.ToSlash(rel))
+	}
+	expected := []string{"docs/a.md", "docs/c.rst", "guide/y.md", "index.md"}
+	if !reflect.DeepEqual(rels, expected) {
+		t.Errorf("Expected files %v, but got %v", expected, rels)
+	}
+}
+
+func TestFindFilesSubdirectory(t *testing.T) {
+	root := t.TempDir()
+	for _, file := range []string{"docs/a.md", "docs/gen/b.md"} {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The patterns of the working directory's file are relative to it,
+	// whatever --dir is
+	writeConfig(t, root, "exclude-paths: [docs/gen/**]\n")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	configs, err := LoadConfigs(".", Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{".", "docs", "./docs", filepath.Join(root, "docs")} {
+		paths, err := FindFiles(dir, []string{".md"}, configs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, path := range paths {
+			names = append(names, filepath.Base(path))
+		}
+		if !reflect.DeepEqual(names, []string{"a.md"}) {
+			t.Errorf("Expected only a.md with --dir %s, but got %v", dir, paths)
+		}
+	}
+}
+
+func TestConfigsOverridePatternsAdd(t *testing.T) {
+	root := t.TempDir()
+	writeConfig(t, root, "ignore-links: [\"https://intranet/**\"]\n")
+
+	configs, err := LoadConfigs(root, Config{IgnoreLinks: []string{"re:localhost"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, _ := configs.For(root)
+	if !cfg.ignores("https://intranet/a") || !cfg.ignores("http://localhost:8080/") || cfg.ignores("https://example.com") {
+		t.Errorf("Expected the ignore-link flags to add to the config, but got %v", cfg.IgnoreLinks)
+	}
+}
@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// junitReporter writes a JUnit XML report with a testcase per file. A file
+// with broken links fails, listing every broken link with its line; a file
+// that could not be validated is an error.
+type junitReporter struct {
+	w     io.Writer
+	suite junitTestSuite
+}
+
+func (r *junitReporter) File(res FileResult) error {
+	testCase := junitTestCase{Name: res.Path, ClassName: "brokenlinks", File: res.Path}
+	if res.Err != nil {
+		testCase.Error = &junitProblem{Message: res.Err.Error(), Type: "error"}
+		r.suite.Errors++
+	}
+
+	var broken []string
+	for _, f := range res.Findings {
+		if f.Severity == SeverityError {
+			broken = append(broken, f.String())
+		}
+	}
+	if len(broken) > 0 {
+		testCase.Failure = &junitProblem{
+			Message: fmt.Sprintf("%d broken links", len(broken)),
+			Type:    "brokenlinks",
+			Text:    strings.Join(broken, "\n"),
+		}
+		r.suite.Failures++
+	}
+
+	r.suite.Tests++
+	r.suite.Cases = append(r.suite.Cases, testCase)
+	return nil
+}
+
+func (r *junitReporter) Finish(summary Summary) error {
+	r.suite.Name = "brokenlinks"
+	report := junitTestSuites{
+		Name:     "brokenlinks",
+		Tests:    r.suite.Tests,
+		Failures: r.suite.Failures,
+		Errors:   r.suite.Errors,
+		Suites:   []junitTestSuite{r.suite},
+	}
+
+	if _, err := io.WriteString(r.w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(r.w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(r.w, "\n")
+	return err
+}
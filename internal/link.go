@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// LinkKind is the kind of check a link needs.
+type LinkKind int
+
+const (
+	FileLink LinkKind = iota
+	WebLink
+	InternalLink
+	ImageLink
+	// ReferenceLink is a reference link without a matching definition.
+	ReferenceLink
+	// UnusedSuppression is a suppression comment that turned off no
+	// findings. It is no link, so it cannot be configured or suppressed.
+	UnusedSuppression
+)
+
+func (k LinkKind) String() string {
+	switch k {
+	case FileLink:
+		return "file"
+	case WebLink:
+		return "web"
+	case InternalLink:
+		return "internal"
+	case ImageLink:
+		return "image"
+	case UnusedSuppression:
+		return "suppression"
+	default:
+		return "reference"
+	}
+}
+
+// MarshalText encodes the kind by its name, like "file".
+func (k LinkKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText decodes a kind from its name.
+func (k *LinkKind) UnmarshalText(text []byte) error {
+	for _, kind := range []LinkKind{FileLink, WebLink, InternalLink, ImageLink, ReferenceLink} {
+		if kind.String() == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown link kind %q", text)
+}
+
+// Link is a link found in a document, with its 1-based line and column.
+type Link struct {
+	Kind   LinkKind
+	Text   string
+	Target string
+	Line   int
+	Column int
+}
+
+// Definition is a link reference definition, like `[label]: target`.
+type Definition struct {
+	Label  string
+	Target string
+	Line   int
+	Column int
+	// Used is set when a reference link in the document refers to it.
+	Used bool
+}
+
+// Document is what a docParser extracts from the source of a document.
+type Document struct {
+	Links       []Link
+	Definitions []Definition
+	// Anchors are the fragments links into the document can point at.
+	Anchors []string
+	// Suppressions turn off the findings of some of its lines.
+	Suppressions []Suppression
+	// Labels are the names the documents of a project can refer to, like
+	// the targets and section titles of reStructuredText.
+	Labels []string
+	// Refs are links to labels, which may be defined in any document of
+	// the project.
+	Refs []Link
+}
+
+var schemeRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+
+// ClassifyLink determines the kind of link for a target, image being set
+// for the targets of images. Links that cannot be checked, like mailto:
+// links or empty targets, are reported as not ok. Targets with a fragment
+// are file links when the format of the file has no parser, like
+// main.go#L10 or spec.pdf#page=2, as its anchors are not known.
+func ClassifyLink(target string, image bool) (LinkKind, bool) {
+	lower := strings.ToLower(target)
+	switch {
+	case target == "":
+		return 0, false
+	case strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://"):
+		return WebLink, true
+	case schemeRegex.MatchString(target):
+		return 0, false
+	case image:
+		return ImageLink, true
+	case strings.Contains(target, "#") && hasAnchors(target):
+		return InternalLink, true
+	default:
+		return FileLink, true
+	}
+}
+
+// localTarget turns the target of a local link into the file it names:
+// the query is dropped and the path unescaped, while the fragment stays
+// escaped. Links to the document itself, like # or ?page=2, are reported as
+// not ok, as there is nothing to check.
+func localTarget(kind LinkKind, target string, image bool) (LinkKind, string, bool) {
+	ref, err := url.Parse(target)
+	if err != nil {
+		return kind, target, true
+	}
+	target = ref.Path
+	if ref.Fragment != "" && !image && hasAnchors(target) {
+		return InternalLink, target + "#" + ref.EscapedFragment(), true
+	}
+	if target == "" {
+		return 0, "", false
+	}
+	if kind == InternalLink {
+		kind = FileLink
+	}
+	return kind, target, true
+}
+
+// hasAnchors reports whether the anchors of the document target points at
+// are known: it is the document itself or its format has a parser.
+func hasAnchors(target string) bool {
+	file, _, _ := strings.Cut(target, "#")
+	file, _, _ = strings.Cut(file, "?")
+	if file == "" {
+		return true
+	}
+	_, ok := parserFor(path.Ext(file), Options{})
+	return ok
+}
+
+// lineIndex converts byte offsets in a source into lines and columns.
+type lineIndex struct {
+	source []byte
+	starts []int
+}
+
+func newLineIndex(source []byte) lineIndex {
+	starts := []int{0}
+	for i, b := range source {
+		if b == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return lineIndex{source: source, starts: starts}
+}
+
+// position returns the 1-based line and column (in runes) of offset.
+func (idx lineIndex) position(offset int) (int, int) {
+	// The line is the last one starting at or before offset
+	line := sort.Search(len(idx.starts), func(i int) bool { return idx.starts[i] > offset }) - 1
+	column := utf8.RuneCount(idx.source[idx.starts[line]:offset]) + 1
+	return line + 1, column
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestClassifyLink(t *testing.T) {
+	tests := []struct {
+		target string
+		image  bool
+		kind   LinkKind
+		ok     bool
+	}{
+		{"glossary.md", false, FileLink, true},
+		{"glossary.md#terms", false, InternalLink, true},
+		{"#terms", false, InternalLink, true},
+		{"guide.rst#usage", false, InternalLink, true},
+		{"main.go#L10", false, FileLink, true},
+		{"spec.pdf#page=2", false, FileLink, true},
+		{"img/btn.png", true, ImageLink, true},
+		{"https://example.com/btn.png", true, WebLink, true},
+		{"HTTP://example.com", false, WebLink, true},
+		{"mailto:someone@example.com", false, 0, false},
+		{"", false, 0, false},
+	}
+
+	for _, tt := range tests {
+		kind, ok := ClassifyLink(tt.target, tt.image)
+		if kind != tt.kind || ok != tt.ok {
+			t.Errorf("Expected %q to be classified as (%s, %v), but got (%s, %v)", tt.target, tt.kind, tt.ok, kind, ok)
+		}
+	}
+}
+
+func TestParserFor(t *testing.T) {
+	tests := map[string]docParser{
+		".md":       markdownParser{},
+		".markdown": markdownParser{},
+		".MDX":      markdownParser{},
+		".rst":      rstParser{},
+		".adoc":     asciidocParser{},
+		".html":     htmlParser{},
+	}
+	for ext, expected := range tests {
+		if p, ok := parserFor(ext, Options{}); !ok || p != expected {
+			t.Errorf("Expected parser %T for %s, but got %T", expected, ext, p)
+		}
+	}
+
+	// Only files picked for validation default to Markdown
+	if p, ok := parserFor(".txt", Options{}); ok {
+		t.Errorf("Expected no parser for .txt, but got %T", p)
+	}
+	if p := fileParser(".txt", Options{}); p != (markdownParser{}) {
+		t.Errorf("Expected .txt files to be validated as Markdown, but got %T", p)
+	}
+}
+
+func TestLineIndexPosition(t *testing.T) {
+	idx := newLineIndex([]byte("ab\n\nüx\ny"))
+
+	tests := []struct {
+		offset int
+		line   int
+		column int
+	}{
+		{0, 1, 1},
+		{2, 1, 3},
+		{3, 2, 1},
+		{4, 3, 1},
+		{6, 3, 2},
+		{8, 4, 1},
+	}
+	for _, tt := range tests {
+		if line, column := idx.position(tt.offset); line != tt.line || column != tt.column {
+			t.Errorf("Expected offset %d at %d:%d, but got %d:%d", tt.offset, tt.line, tt.column, line, column)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package internal
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// markdown parses CommonMark, with attribute lists like `{#custom-id}` on
+// headings.
+var markdown = goldmark.New(goldmark.WithParserOptions(parser.WithAttribute())).Parser()
+
+// markdownParser extracts links by walking the CommonMark AST of a
+// document, so every inline link, image, autolink and resolved reference
+// link is found regardless of what its text contains. Code blocks and code
+// spans are only searched for links when includeCode is set. Local targets
+// lose their query and are unescaped, so they name a file.
+type markdownParser struct {
+	includeCode bool
+}
+
+func (p markdownParser) parse(source []byte, slugger Slugger) Document {
+	root := markdown.Parse(text.NewReader(source))
+	idx := newLineIndex(source)
+
+	links := p.links(root, source, 0, idx)
+	links = append(links, undefinedReferences(root, source, idx)...)
+	sort.SliceStable(links, func(i, j int) bool {
+		if links[i].Line != links[j].Line {
+			return links[i].Line < links[j].Line
+		}
+		return links[i].Column < links[j].Column
+	})
+	return Document{
+		Links:       links,
+		Definitions: definitions(root, idx),
+		Anchors:     anchors(root, source, slugger),
+	}
+}
+
+// links returns the links below root, parsed from source, a part of the
+// document indexed by idx that starts at offset.
+func (p markdownParser) links(root ast.Node, source []byte, offset int, idx lineIndex) []Link {
+	var links []Link
+	add := func(n ast.Node, target string, image bool) {
+		kind, ok := ClassifyLink(target, image)
+		if ok && kind != WebLink {
+			kind, target, ok = localTarget(kind, target, image)
+		}
+		if !ok {
+			return
+		}
+		line, column := idx.position(offset + n.Pos())
+		links = append(links, Link{
+			Kind:   kind,
+			Text:   nodeText(n, source),
+			Target: target,
+			Line:   line,
+			Column: column,
+		})
+	}
+
+	_ = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Link:
+			add(node, string(node.Destination), false)
+		case *ast.Image:
+			add(node, string(node.Destination), true)
+		case *ast.AutoLink:
+			if node.AutoLinkType == ast.AutoLinkURL {
+				add(node, string(node.URL(source)), false)
+			}
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			if p.includeCode {
+				lines := node.Lines()
+				for i := 0; i < lines.Len(); i++ {
+					links = append(links, p.codeLinks(source, lines.At(i), offset, idx)...)
+				}
+			}
+		case *ast.CodeSpan:
+			if p.includeCode {
+				for c := node.FirstChild(); c != nil; c = c.NextSibling() {
+					if t, ok := c.(*ast.Text); ok {
+						links = append(links, p.codeLinks(source, t.Segment, offset, idx)...)
+					}
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return links
+}
+
+// codeLinks parses a line of code as Markdown on its own. Leading
+// indentation is dropped so the line is not taken for a code block again.
+func (p markdownParser) codeLinks(source []byte, seg text.Segment, offset int, idx lineIndex) []Link {
+	value := seg.Value(source)
+	trimmed := bytes.TrimLeft(value, " \t")
+	start := offset + seg.Start + len(value) - len(trimmed)
+	root := markdown.Parse(text.NewReader(trimmed))
+	return p.links(root, trimmed, start, idx)
+}
+
+// htmlIDRegex matches id attributes of any element and name attributes of
+// <a> elements, both of which can be the target of a link.
+var htmlIDRegex = regexp.MustCompile(`<(?:[a-zA-Z][a-zA-Z0-9-]*\s[^>]*?\bid|[aA]\s[^>]*?\bname)\s*=\s*["']([^"']+)["']`)
+
+// anchors returns the anchors of a document in document order: the slugs
+// of its ATX and Setext headings, or their `{#custom-id}`, and the ids of
+// its raw HTML. Headings and HTML inside code are ignored.
+func anchors(root ast.Node, source []byte, slugger Slugger) []string {
+	var anchors []string
+	addHTML := func(segments *text.Segments) {
+		for i := 0; i < segments.Len(); i++ {
+			seg := segments.At(i)
+			for _, id := range htmlIDRegex.FindAllSubmatch(seg.Value(source), -1) {
+				anchors = append(anchors, string(id[1]))
+			}
+		}
+	}
+
+	_ = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Heading:
+			if id, ok := node.AttributeString("id"); ok {
+				if b, ok := id.([]byte); ok {
+					anchors = append(anchors, string(b))
+				}
+			} else {
+				anchors = append(anchors, slugger.Slug(nodeText(node, source)))
+			}
+		case *ast.HTMLBlock:
+			addHTML(node.Lines())
+			if node.HasClosure() {
+				segments := text.NewSegments()
+				segments.Append(node.ClosureLine)
+				addHTML(segments)
+			}
+		case *ast.RawHTML:
+			addHTML(node.Segments)
+		}
+		return ast.WalkContinue, nil
+	})
+	return anchors
+}
+
+// definitions returns the link reference definitions of a document, marking
+// the ones used by a reference link.
+func definitions(root ast.Node, idx lineIndex) []Definition {
+	var defs []Definition
+	used := map[string]bool{}
+	_ = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.LinkReferenceDefinition:
+			line, column := idx.position(node.Pos())
+			defs = append(defs, Definition{
+				Label:  string(node.Label),
+				Target: string(node.Destination),
+				Line:   line,
+				Column: column,
+			})
+		case *ast.Link:
+			if node.Reference != nil {
+				used[normalizeLabel(string(node.Reference.Value))] = true
+			}
+		case *ast.Image:
+			if node.Reference != nil {
+				used[normalizeLabel(string(node.Reference.Value))] = true
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	for i := range defs {
+		defs[i].Used = used[normalizeLabel(defs[i].Label)]
+	}
+	return defs
+}
+
+// normalizeLabel matches labels the CommonMark way: case-insensitive and
+// with consecutive whitespace collapsed. docutils and Sphinx compare
+// reference names and labels the same way.
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+// referenceRegex matches full `[text][label]` and collapsed `[text][]`
+// references. Shortcut references are not reported, as brackets around
+// plain text are too common to tell them apart. Neither are brackets that
+// directly follow a word, like the indices in matrix[i][j].
+var referenceRegex = regexp.MustCompile(`\[([^\[\]\x00]+)\]\[([^\[\]\x00]*)\]`)
+
+// undefinedReferences returns the reference links whose label has no
+// definition. The parser leaves those as plain text, so the text of every
+// paragraph and heading is searched, with links and code masked out.
+func undefinedReferences(root ast.Node, source []byte, idx lineIndex) []Link {
+	var links []Link
+	_ = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || n.Type() != ast.TypeBlock || n.FirstChild() == nil || n.FirstChild().Type() != ast.TypeInline {
+			return ast.WalkContinue, nil
+		}
+
+		var buf []byte
+		var offsets []int
+		_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+			if !entering || c == n {
+				return ast.WalkContinue, nil
+			}
+			switch node := c.(type) {
+			case *ast.Text:
+				for i := node.Segment.Start; i < node.Segment.Stop; i++ {
+					buf = append(buf, source[i])
+					offsets = append(offsets, i)
+				}
+				if node.SoftLineBreak() {
+					buf = append(buf, ' ')
+					offsets = append(offsets, node.Segment.Stop)
+				}
+			case *ast.Link, *ast.Image, *ast.AutoLink, *ast.CodeSpan, *ast.RawHTML:
+				buf = append(buf, 0)
+				offsets = append(offsets, node.Pos())
+				return ast.WalkSkipChildren, nil
+			}
+			return ast.WalkContinue, nil
+		})
+
+		for _, m := range referenceRegex.FindAllSubmatchIndex(buf, -1) {
+			start := offsets[m[0]]
+			if start > 0 && source[start-1] == '\\' {
+				continue
+			}
+			if r, _ := utf8.DecodeLastRune(buf[:m[0]]); r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+				continue
+			}
+			label := string(buf[m[4]:m[5]])
+			if label == "" {
+				label = string(buf[m[2]:m[3]])
+			}
+			line, column := idx.position(start)
+			links = append(links, Link{
+				Kind:   ReferenceLink,
+				Text:   string(buf[m[2]:m[3]]),
+				Target: label,
+				Line:   line,
+				Column: column,
+			})
+		}
+		return ast.WalkSkipChildren, nil
+	})
+	return links
+}
+
+// nodeText returns the plain text of the inline children of n.
+func nodeText(n ast.Node, source []byte) string {
+	var sb strings.Builder
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := c.(type) {
+		case *ast.Text:
+			sb.Write(node.Segment.Value(source))
+		case *ast.String:
+			sb.Write(node.Value)
+		case *ast.AutoLink:
+			sb.Write(node.Label(source))
+		}
+		return ast.WalkContinue, nil
+	})
+	return sb.String()
+}
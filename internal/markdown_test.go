@@ -0,0 +1,246 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExtractMarkdownLinks(t *testing.T) {
+	source := " asdfas df [glossary](../testfiles/glossary.md) or a [correct](../testfiles/correct.md), ... ![image](../img/glossary.png) and [corrupt](../testfiles/corrupt.md)"
+
+	links := markdownParser{}.parse([]byte(source), NewGitHubSlugger()).Links
+
+	expected := []Link{
+		{Kind: FileLink, Text: "glossary", Target: "../testfiles/glossary.md", Line: 1, Column: 12},
+		{Kind: FileLink, Text: "correct", Target: "../testfiles/correct.md", Line: 1, Column: 54},
+		{Kind: ImageLink, Text: "image", Target: "../img/glossary.png", Line: 1, Column: 94},
+		{Kind: FileLink, Text: "corrupt", Target: "../testfiles/corrupt.md", Line: 1, Column: 128},
+	}
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("Expected links:\n%v\nBut got:\n%v", expected, links)
+	}
+}
+
+func TestExtractMarkdownLinkKinds(t *testing.T) {
+	source := "To illustrate, if fixed [retry strategy](../abc/01-03-0002-retry-strategy.md).\n" +
+		"Alternatively, it may implement an [exponential backoff](../d/file.md#Exponential-Backoff), see <https://example.com/a>\n" +
+		"or [GitHub](http://github.com) and [mail](mailto:someone@example.com)"
+
+	links := markdownParser{}.parse([]byte(source), NewGitHubSlugger()).Links
+
+	expected := []Link{
+		{Kind: FileLink, Text: "retry strategy", Target: "../abc/01-03-0002-retry-strategy.md", Line: 1, Column: 25},
+		{Kind: InternalLink, Text: "exponential backoff", Target: "../d/file.md#Exponential-Backoff", Line: 2, Column: 36},
+		{Kind: WebLink, Text: "https://example.com/a", Target: "https://example.com/a", Line: 2, Column: 97},
+		{Kind: WebLink, Text: "GitHub", Target: "http://github.com", Line: 3, Column: 4},
+	}
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("Expected links:\n%v\nBut got:\n%v", expected, links)
+	}
+}
+
+func TestExtractMarkdownLinkTexts(t *testing.T) {
+	// The regex based extraction only accepted [a-zA-Z0-9 ]+ as link text
+	source := "[foo_bar](a.md) [`code`](b.md) [some *emphasis*](c.md) [Ünïcode](d.md)"
+
+	links := markdownParser{}.parse([]byte(source), NewGitHubSlugger()).Links
+
+	expected := []string{"foo_bar", "code", "some emphasis", "Ünïcode"}
+	if len(links) != len(expected) {
+		t.Fatalf("Expected %d links, but got %d", len(expected), len(links))
+	}
+	for i, link := range links {
+		if link.Text != expected[i] {
+			t.Errorf("Expected link text '%s', but got '%s'", expected[i], link.Text)
+		}
+	}
+}
+
+func TestExtractMarkdownReferenceLinks(t *testing.T) {
+	source := "See [the glossary][glossary] for details.\n\n[glossary]: ../testfiles/glossary.md\n"
+
+	links := markdownParser{}.parse([]byte(source), NewGitHubSlugger()).Links
+
+	expected := []Link{
+		{Kind: FileLink, Text: "the glossary", Target: "../testfiles/glossary.md", Line: 1, Column: 5},
+	}
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("Expected links:\n%v\nBut got:\n%v", expected, links)
+	}
+}
+
+func TestExtractMarkdownLocalTargets(t *testing.T) {
+	tests := []struct {
+		source string
+		kind   LinkKind
+		target string
+		ok     bool
+	}{
+		{"[top](#)", 0, "", false},
+		{"[a](my%20file.md)", FileLink, "my file.md", true},
+		{"[a](b.md?raw=1)", FileLink, "b.md", true},
+		{"[a](b.md?raw=1#usage)", InternalLink, "b.md#usage", true},
+		{"[a](b.md#)", FileLink, "b.md", true},
+		{"[a](#caf%C3%A9)", InternalLink, "#caf%C3%A9", true},
+		{"[a](main.go#L10)", FileLink, "main.go", true},
+		{"[a](spec.pdf?v=2#page=2)", FileLink, "spec.pdf", true},
+		{"![a](img/my%20logo.png?v=2)", ImageLink, "img/my logo.png", true},
+		{"[a](https://example.com/a%20b?q=1)", WebLink, "https://example.com/a%20b?q=1", true},
+	}
+
+	for _, tt := range tests {
+		links := markdownParser{}.parse([]byte(tt.source), NewGitHubSlugger()).Links
+		if !tt.ok {
+			if len(links) != 0 {
+				t.Errorf("Expected no links in %q, but got %v", tt.source, links)
+			}
+			continue
+		}
+		if len(links) != 1 || links[0].Kind != tt.kind || links[0].Target != tt.target {
+			t.Errorf("Expected %q to link to %s %q, but got %v", tt.source, tt.kind, tt.target, links)
+		}
+	}
+}
+
+func TestValidateFileUnparsedFragments(t *testing.T) {
+	// Fragments of files without a parser, like line numbers of source
+	// files and pages of PDFs, are not checked
+	root := t.TempDir()
+	files := map[string]string{
+		"index.md": "[code](main.go#L1)\n[pdf](spec.pdf#page=2)\n[gone](gone.pdf#page=1)\n",
+		"main.go":  "package main\n",
+		"spec.pdf": "%PDF-1.7\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	findings, summary, err := validateFile(context.Background(), filepath.Join(root, "index.md"), ".md", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFindings(t, findings, []expectedFinding{
+		{3, "gone.pdf", "broken file link"},
+	})
+	if summary.BrokenLinks() != 1 || summary.Broken[FileLink] != 1 {
+		t.Errorf("Expected 1 broken file link, but got %+v", summary)
+	}
+}
+
+func TestValidateFileMarkdownLocalTargets(t *testing.T) {
+	root := t.TempDir()
+	source := "# Top\n\n[top](#)\n[a](my%20file.md)\n[a](b.md?raw=1)\n[a](b.md?raw=1#usage)\n"
+	files := map[string]string{
+		"index.md":   source,
+		"my file.md": "",
+		"b.md":       "## Usage\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	findings, _, err := validateFile(context.Background(), filepath.Join(root, "index.md"), ".md", Options{})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("Expected no findings, but got %v", findings)
+	}
+}
+
+const codeSource = "Some `[inline](inline.md)` code\n" +
+	"\n" +
+	"```shell\n" +
+	"see [fenced](fenced.md)\n" +
+	"```\n" +
+	"\n" +
+	"    [indented](indented.md)\n" +
+	"\n" +
+	"and a [real](real.md) link\n"
+
+func TestExtractMarkdownSkipsCode(t *testing.T) {
+	links := markdownParser{}.parse([]byte(codeSource), NewGitHubSlugger()).Links
+
+	expected := []Link{
+		{Kind: FileLink, Text: "real", Target: "real.md", Line: 9, Column: 7},
+	}
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("Expected links:\n%v\nBut got:\n%v", expected, links)
+	}
+}
+
+func TestExtractMarkdownIncludeCode(t *testing.T) {
+	links := markdownParser{includeCode: true}.parse([]byte(codeSource), NewGitHubSlugger()).Links
+
+	expected := []Link{
+		{Kind: FileLink, Text: "inline", Target: "inline.md", Line: 1, Column: 7},
+		{Kind: FileLink, Text: "fenced", Target: "fenced.md", Line: 4, Column: 5},
+		{Kind: FileLink, Text: "indented", Target: "indented.md", Line: 7, Column: 5},
+		{Kind: FileLink, Text: "real", Target: "real.md", Line: 9, Column: 7},
+	}
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("Expected links:\n%v\nBut got:\n%v", expected, links)
+	}
+}
+
+func TestMarkdownReferences(t *testing.T) {
+	source, err := os.ReadFile("../testfiles/references.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := markdownParser{}.parse(source, NewGitHubSlugger())
+
+	expectedLinks := []Link{
+		{Kind: FileLink, Text: "glossary", Target: "glossary.md", Line: 3, Column: 28},
+		{Kind: FileLink, Text: "Correct", Target: "./correct.md", Line: 3, Column: 51},
+		{Kind: FileLink, Text: "subdir", Target: "./subdir/bla.md", Line: 4, Column: 1},
+		{Kind: FileLink, Text: "button", Target: "img/btn.png", Line: 4, Column: 19},
+		{Kind: ImageLink, Text: "cool button", Target: "img/btn.png", Line: 4, Column: 40},
+		{Kind: InternalLink, Text: "heading", Target: "./subdir/bla.md#headers-2-with-extra-text", Line: 6, Column: 11},
+		{Kind: ReferenceLink, Text: "missing one", Target: "nowhere", Line: 6, Column: 31},
+	}
+	if !reflect.DeepEqual(doc.Links, expectedLinks) {
+		t.Errorf("Expected links:\n%v\nBut got:\n%v", expectedLinks, doc.Links)
+	}
+
+	var unused []string
+	for _, def := range doc.Definitions {
+		if !def.Used {
+			unused = append(unused, def.Label)
+		}
+	}
+	if len(doc.Definitions) != 6 || !reflect.DeepEqual(unused, []string{"unused"}) {
+		t.Errorf("Expected 6 definitions with only 'unused' unused, but got %v", doc.Definitions)
+	}
+}
+
+func TestMarkdownReferencesInProse(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected []string
+	}{
+		{"Use matrix[i][j] in prose.", nil},
+		{"Index with `a[b]`, grid[0][1] or café[x][y].", nil},
+		{"A [missing][nowhere] reference.", []string{"nowhere"}},
+		{"(see [this][])", []string{"this"}},
+	}
+
+	for _, tt := range tests {
+		var targets []string
+		doc := markdownParser{}.parse([]byte(tt.source), NewGitHubSlugger())
+		for _, link := range doc.Links {
+			targets = append(targets, link.Target)
+		}
+		if !reflect.DeepEqual(targets, tt.expected) {
+			t.Errorf("Expected references %v in %q, but got %v", tt.expected, tt.source, targets)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// docParser extracts the links and anchors from the source of a document,
+// using slugger to turn its headings into anchors.
+type docParser interface {
+	parse(source []byte, slugger Slugger) Document
+}
+
+// Parser extracts the links and anchors of a document format, see
+// RegisterParser. Links are resolved relative to the document; use
+// ClassifyLink to pick their kind. Reference links are reported as
+// undefined, so a Parser resolves the references of its format itself.
+type Parser interface {
+	// Links returns the links of a document with their 1-based line and
+	// column.
+	Links(source []byte) []Link
+	// Anchors returns the fragments links into the document can point at,
+	// using slugger for headings that get their anchor from the renderer.
+	Anchors(source []byte, slugger Slugger) []string
+}
+
+// parserAdapter runs a registered Parser as a docParser.
+type parserAdapter struct {
+	p Parser
+}
+
+func (a parserAdapter) parse(source []byte, slugger Slugger) Document {
+	return Document{Links: a.p.Links(source), Anchors: a.p.Anchors(source, slugger)}
+}
+
+// parsers maps file extensions, in lower case, to the constructor of their
+// parser. Documents are parsed according to their own extension, so links
+// between documents of different formats are checked against the anchors
+// of the target's format.
+var (
+	parsersMu sync.RWMutex
+	parsers   = map[string]func(opts Options) docParser{
+		".md":       newMarkdownParser,
+		".markdown": newMarkdownParser,
+		".mdx":      newMarkdownParser,
+		".rst":      func(opts Options) docParser { return rstParser{includeCode: opts.IncludeCode} },
+		".adoc":     func(opts Options) docParser { return asciidocParser{includeCode: opts.IncludeCode} },
+		".asciidoc": func(opts Options) docParser { return asciidocParser{includeCode: opts.IncludeCode} },
+		".html":     func(Options) docParser { return htmlParser{} },
+		".htm":      func(Options) docParser { return htmlParser{} },
+	}
+)
+
+func newMarkdownParser(opts Options) docParser {
+	return markdownParser{includeCode: opts.IncludeCode}
+}
+
+// RegisterParser makes p the parser of the files with one of extensions,
+// like ".org", replacing the parser registered before, built in ones
+// included. It is meant to be called from an init function, before any
+// file is validated.
+func RegisterParser(p Parser, extensions ...string) error {
+	if p == nil {
+		return fmt.Errorf("parser is nil")
+	}
+	for _, ext := range extensions {
+		if !strings.HasPrefix(ext, ".") || len(ext) < 2 {
+			return fmt.Errorf("extension %q must start with a dot", ext)
+		}
+	}
+
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	for _, ext := range extensions {
+		parsers[strings.ToLower(ext)] = func(Options) docParser { return parserAdapter{p: p} }
+	}
+	return nil
+}
+
+// Extensions returns the file extensions with a parser of their own. Files
+// with any other extension are parsed as Markdown when they are validated,
+// while links into them are only checked to point at an existing file.
+func Extensions() []string {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+	var exts []string
+	for ext := range parsers {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// errNoParser is returned for documents whose extension has no parser, so
+// their anchors are not known.
+var errNoParser = errors.New("no parser for the extension of the document")
+
+// parserFor returns the link extractor for a file extension, if it has
+// one.
+func parserFor(extension string, opts Options) (docParser, bool) {
+	parsersMu.RLock()
+	newParser, ok := parsers[strings.ToLower(extension)]
+	parsersMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return newParser(opts), true
+}
+
+// fileParser returns the link extractor for a file picked for validation,
+// like by --ext, which is parsed as Markdown when its extension has no
+// parser of its own.
+func fileParser(extension string, opts Options) docParser {
+	if parser, ok := parserFor(extension, opts); ok {
+		return parser
+	}
+	return newMarkdownParser(opts)
+}
@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ReportVersion is the version of the JSON and NDJSON report schema. It is
+// raised whenever a field is removed or changes meaning.
+const ReportVersion = 1
+
+// Reporter renders the results of a run. File is called for every file in
+// the order the files were given, Finish once all files are done.
+type Reporter interface {
+	File(res FileResult) error
+	Finish(summary Summary) error
+}
+
+// ReportOptions controls the reports meant to be read by people.
+type ReportOptions struct {
+	// Verbose also lists the files that are validated.
+	Verbose bool
+	// Color colors errors red and warnings yellow.
+	Color bool
+}
+
+// reporters maps the names accepted by NewReporter to their constructors.
+var reporters = map[string]func(w io.Writer, opts ReportOptions) Reporter{
+	"text":   func(w io.Writer, opts ReportOptions) Reporter { return &textReporter{w: w, opts: opts} },
+	"json":   func(w io.Writer, opts ReportOptions) Reporter { return &jsonReporter{w: w} },
+	"ndjson": func(w io.Writer, opts ReportOptions) Reporter { return newNDJSONReporter(w) },
+	"sarif":  func(w io.Writer, opts ReportOptions) Reporter { return &sarifReporter{w: w} },
+	"junit":  func(w io.Writer, opts ReportOptions) Reporter { return &junitReporter{w: w} },
+	"github": func(w io.Writer, opts ReportOptions) Reporter { return &githubReporter{w: w} },
+}
+
+// ReportFormats returns the names of the supported report formats.
+func ReportFormats() []string {
+	var names []string
+	for name := range reporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewReporter returns a Reporter writing the report format to w. The
+// machine readable formats ignore opts.
+func NewReporter(format string, w io.Writer, opts ReportOptions) (Reporter, error) {
+	newReporter, ok := reporters[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q, expected one of: %s", format, strings.Join(ReportFormats(), ", "))
+	}
+	return newReporter(w, opts), nil
+}
+
+// textReporter writes the classic text report followed by the summary.
+type textReporter struct {
+	w    io.Writer
+	opts ReportOptions
+}
+
+func (r *textReporter) File(res FileResult) error {
+	if r.opts.Verbose {
+		fmt.Fprintf(r.w, "# Validating %s \n", res.Path)
+	}
+	WriteText(r.w, res.Findings, r.opts.Color)
+	if res.Err != nil {
+		fmt.Fprintf(r.w, "# Error validating links in file %s: %v\n", res.Path, res.Err)
+	}
+	return nil
+}
+
+func (r *textReporter) Finish(summary Summary) error {
+	summary.Write(r.w)
+	return nil
+}
+
+// jsonSummary is the summary of the JSON report.
+type jsonSummary struct {
+	Files        int              `json:"files"`
+	Links        int              `json:"links"`
+	Broken       int              `json:"broken"`
+	BrokenByKind map[LinkKind]int `json:"broken_by_kind"`
+}
+
+// jsonError is a file that could not be validated.
+type jsonError struct {
+	File    string `json:"file"`
+	Message string `json:"message"`
+}
+
+// jsonReport is the single document written by the json format.
+type jsonReport struct {
+	Version  int         `json:"version"`
+	Summary  jsonSummary `json:"summary"`
+	Findings []Finding   `json:"findings"`
+	Errors   []jsonError `json:"errors"`
+}
+
+// jsonReporter collects all findings and writes them as one document.
+type jsonReporter struct {
+	w      io.Writer
+	report jsonReport
+}
+
+func (r *jsonReporter) File(res FileResult) error {
+	r.report.Findings = append(r.report.Findings, res.Findings...)
+	if res.Err != nil {
+		r.report.Errors = append(r.report.Errors, jsonError{File: res.Path, Message: res.Err.Error()})
+	}
+	return nil
+}
+
+func (r *jsonReporter) Finish(summary Summary) error {
+	r.report.Version = ReportVersion
+	r.report.Summary = jsonSummary{
+		Files:        summary.Files,
+		Links:        summary.Links,
+		Broken:       summary.BrokenLinks(),
+		BrokenByKind: map[LinkKind]int{},
+	}
+	for kind, n := range summary.Broken {
+		if n > 0 {
+			r.report.Summary.BrokenByKind[kind] = n
+		}
+	}
+	// Consumers should not have to tell null from an empty list
+	if r.report.Findings == nil {
+		r.report.Findings = []Finding{}
+	}
+	if r.report.Errors == nil {
+		r.report.Errors = []jsonError{}
+	}
+
+	enc := json.NewEncoder(r.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r.report)
+}
+
+// ndjsonFinding is a line of the ndjson format.
+type ndjsonFinding struct {
+	Version int `json:"version"`
+	Finding
+}
+
+// ndjsonReporter writes every finding as soon as its file is done, one
+// JSON object per line. Files that could not be validated are reported on
+// errW, so every line of the report is a finding.
+type ndjsonReporter struct {
+	enc  *json.Encoder
+	errW io.Writer
+}
+
+func newNDJSONReporter(w io.Writer) *ndjsonReporter {
+	return &ndjsonReporter{enc: json.NewEncoder(w), errW: os.Stderr}
+}
+
+func (r *ndjsonReporter) File(res FileResult) error {
+	if res.Err != nil {
+		fmt.Fprintf(r.errW, "# Error validating links in file %s: %v\n", res.Path, res.Err)
+	}
+	for _, f := range res.Findings {
+		if err := r.enc.Encode(ndjsonFinding{Version: ReportVersion, Finding: f}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ndjsonReporter) Finish(summary Summary) error {
+	return nil
+}
@@ -0,0 +1,232 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var reportFindings = []Finding{
+	{File: "a.md", Line: 3, Column: 5, Text: "b", Target: "b.md", Kind: FileLink, Severity: SeverityError, Message: "broken file link"},
+	{File: "a.md", Line: 4, Column: 1, Text: "web", Target: "https://example.com", Kind: WebLink, Severity: SeverityError, Message: "broken web link", Detail: "404 Not Found"},
+}
+
+func reportResults(t *testing.T, format string) string {
+	var buf bytes.Buffer
+	reporter, err := NewReporter(format, &buf, ReportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := Summary{Files: 2, Links: 5, Broken: map[LinkKind]int{FileLink: 1, WebLink: 1}}
+	_ = reporter.File(FileResult{Path: "a.md", Findings: reportFindings})
+	_ = reporter.File(FileResult{Path: "gone.md", Err: errors.New("no such file")})
+	if err := reporter.Finish(summary); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestJSONReport(t *testing.T) {
+	var report jsonReport
+	if err := json.Unmarshal([]byte(reportResults(t, "json")), &report); err != nil {
+		t.Fatalf("Expected a single JSON document, but got error: %v", err)
+	}
+
+	if report.Version != ReportVersion {
+		t.Errorf("Expected version %d, but got %d", ReportVersion, report.Version)
+	}
+	expectedSummary := jsonSummary{Files: 2, Links: 5, Broken: 2, BrokenByKind: map[LinkKind]int{FileLink: 1, WebLink: 1}}
+	if !reflect.DeepEqual(report.Summary, expectedSummary) {
+		t.Errorf("Expected summary %v, but got %v", expectedSummary, report.Summary)
+	}
+	if !reflect.DeepEqual(report.Findings, reportFindings) {
+		t.Errorf("Expected findings:\n%v\nBut got:\n%v", reportFindings, report.Findings)
+	}
+	if len(report.Errors) != 1 || report.Errors[0].File != "gone.md" {
+		t.Errorf("Expected gone.md to be reported as error, but got %v", report.Errors)
+	}
+}
+
+func TestJSONReportFieldNames(t *testing.T) {
+	var report map[string]interface{}
+	_ = json.Unmarshal([]byte(reportResults(t, "json")), &report)
+
+	finding := report["findings"].([]interface{})[1].(map[string]interface{})
+	for _, field := range []string{"file", "line", "column", "text", "target", "kind", "severity", "message", "detail"} {
+		if _, ok := finding[field]; !ok {
+			t.Errorf("Expected field %s in finding %v", field, finding)
+		}
+	}
+	if finding["kind"] != "web" || finding["severity"] != "error" {
+		t.Errorf("Expected kind and severity by name, but got %v and %v", finding["kind"], finding["severity"])
+	}
+}
+
+func TestNDJSONReport(t *testing.T) {
+	scanner := bufio.NewScanner(bytes.NewBufferString(reportResults(t, "ndjson")))
+
+	var findings []Finding
+	for scanner.Scan() {
+		var line ndjsonFinding
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("Expected a JSON object per line, but got error: %v", err)
+		}
+		if line.Version != ReportVersion {
+			t.Errorf("Expected version %d, but got %d", ReportVersion, line.Version)
+		}
+		findings = append(findings, line.Finding)
+	}
+	if !reflect.DeepEqual(findings, reportFindings) {
+		t.Errorf("Expected findings:\n%v\nBut got:\n%v", reportFindings, findings)
+	}
+}
+
+func TestNewReporterUnknownFormat(t *testing.T) {
+	if _, err := NewReporter("xml", &bytes.Buffer{}, ReportOptions{}); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+}
+
+func TestSARIFReport(t *testing.T) {
+	var log sarifLog
+	if err := json.Unmarshal([]byte(reportResults(t, "sarif")), &log); err != nil {
+		t.Fatalf("Expected a SARIF log, but got error: %v", err)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("Expected a single SARIF 2.1.0 run, but got %s with %d runs", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != len(sarifRules) {
+		t.Errorf("Expected a rule per link kind, but got %v", run.Tool.Driver.Rules)
+	}
+	if run.Invocations[0].ExecutionSuccessful || len(run.Invocations[0].ToolExecutionNotifications) != 1 {
+		t.Errorf("Expected gone.md to be reported as notification, but got %v", run.Invocations)
+	}
+
+	expected := []struct {
+		ruleID  string
+		message string
+		line    int
+		column  int
+	}{
+		{"broken-file-link", "broken file link: b.md", 3, 5},
+		{"broken-web-link", "broken web link: https://example.com (404 Not Found)", 4, 1},
+	}
+	if len(run.Results) != len(expected) {
+		t.Fatalf("Expected %d results, but got %v", len(expected), run.Results)
+	}
+	for i, res := range run.Results {
+		location := res.Locations[0].PhysicalLocation
+		if res.RuleID != expected[i].ruleID || run.Tool.Driver.Rules[res.RuleIndex].ID != res.RuleID || res.Level != "error" {
+			t.Errorf("Expected an error of rule %s, but got %v", expected[i].ruleID, res)
+		}
+		if res.Message.Text != expected[i].message {
+			t.Errorf("Expected message '%s', but got '%s'", expected[i].message, res.Message.Text)
+		}
+		if location.ArtifactLocation.URI != "a.md" || location.ArtifactLocation.URIBaseID != "%SRCROOT%" || location.Region.StartLine != expected[i].line || location.Region.StartColumn != expected[i].column {
+			t.Errorf("Expected a.md:%d:%d, but got %v", expected[i].line, expected[i].column, location)
+		}
+	}
+
+	var buf bytes.Buffer
+	reporter, _ := NewReporter("sarif", &buf, ReportOptions{})
+	_ = reporter.File(FileResult{Path: "a.md", Findings: []Finding{
+		{File: "a.md", Line: 2, Column: 1, Target: "brokenlinks-disable-next-line web", Kind: UnusedSuppression, Severity: SeverityWarning, Message: "unused suppression"},
+	}})
+	_ = reporter.Finish(Summary{Files: 1})
+	log = sarifLog{}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Expected a SARIF log, but got error: %v", err)
+	}
+	results := log.Runs[0].Results
+	if len(results) != 1 || results[0].RuleID != "unused-suppression" || log.Runs[0].Tool.Driver.Rules[results[0].RuleIndex].ID != "unused-suppression" || results[0].Level != "warning" {
+		t.Errorf("Expected an unused-suppression warning, but got %v", results)
+	}
+}
+
+func TestSARIFArtifactLocations(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	reporter := &sarifReporter{root: root}
+
+	tests := []struct {
+		path     string
+		expected sarifArtifactLocation
+	}{
+		{filepath.Join(root, "docs", "a.md"), sarifArtifactLocation{URI: "docs/a.md", URIBaseID: "%SRCROOT%"}},
+		{filepath.Join(root, "my docs", "a#1.md"), sarifArtifactLocation{URI: "my%20docs/a%231.md", URIBaseID: "%SRCROOT%"}},
+		{filepath.Join(outside, "b.md"), sarifArtifactLocation{URI: fileURI(filepath.Join(outside, "b.md"))}},
+	}
+	for _, tt := range tests {
+		if location := reporter.artifact(tt.path); location != tt.expected {
+			t.Errorf("Expected %s at %+v, but got %+v", tt.path, tt.expected, location)
+		}
+	}
+	if uri := fileURI(filepath.Join(outside, "b.md")); !strings.HasPrefix(uri, "file:///") || strings.Contains(uri, "\\") {
+		t.Errorf("Expected an absolute file URI, but got %s", uri)
+	}
+
+	var buf bytes.Buffer
+	reporter = &sarifReporter{w: &buf, root: root}
+	if err := reporter.Finish(Summary{}); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if base := log.Runs[0].OriginalURIBaseIDs["%SRCROOT%"]; base.URI != fileURI(root)+"/" {
+		t.Errorf("Expected %%SRCROOT%% to be %s/, but got %+v", fileURI(root), base)
+	}
+}
+
+func TestJUnitReport(t *testing.T) {
+	var report junitTestSuites
+	if err := xml.Unmarshal([]byte(reportResults(t, "junit")), &report); err != nil {
+		t.Fatalf("Expected a JUnit XML report, but got error: %v", err)
+	}
+
+	if report.Tests != 2 || report.Failures != 1 || report.Errors != 1 || len(report.Suites) != 1 {
+		t.Fatalf("Expected 2 tests with 1 failure and 1 error in a suite, but got %+v", report)
+	}
+	cases := report.Suites[0].Cases
+	if cases[0].Name != "a.md" || cases[0].Failure == nil || cases[0].Error != nil {
+		t.Fatalf("Expected a.md to fail, but got %+v", cases[0])
+	}
+	expected := "# broken file link in file a.md:3 issue: b.md\n" +
+		"# broken web link in file a.md:4 issue: https://example.com (404 Not Found)"
+	if cases[0].Failure.Message != "2 broken links" || cases[0].Failure.Text != expected {
+		t.Errorf("Expected failure listing the broken links:\n%s\nBut got:\n%s: %s", expected, cases[0].Failure.Message, cases[0].Failure.Text)
+	}
+	if cases[1].Name != "gone.md" || cases[1].Error == nil || cases[1].Failure != nil {
+		t.Errorf("Expected gone.md to be an error, but got %+v", cases[1])
+	}
+}
+
+func TestGitHubReport(t *testing.T) {
+	report := reportResults(t, "github")
+
+	expected := "::error file=a.md,line=3,col=5,title=broken file link::broken file link b.md\n" +
+		"::error file=a.md,line=4,col=1,title=broken web link::broken web link https://example.com (404 Not Found)\n" +
+		"::error file=gone.md::no such file\n" +
+		"# Files scanned: 2, links checked: 5, broken links: 2\n"
+	if !strings.HasPrefix(report, expected) {
+		t.Errorf("Expected workflow commands:\n%s\nBut got:\n%s", expected, report)
+	}
+}
+
+func TestGitHubEscaping(t *testing.T) {
+	if res := githubProperty("docs/a,b:c.md"); res != "docs/a%2Cb%3Ac.md" {
+		t.Errorf("Expected escaped property, but got '%s'", res)
+	}
+	if res := githubData("100%\nbroken"); res != "100%25%0Abroken" {
+		t.Errorf("Expected escaped data, but got '%s'", res)
+	}
+}
@@ -0,0 +1,333 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// rstParser extracts the links of reStructuredText documents:
+//
+//   - image, figure and include directives,
+//   - hyperlink references with an embedded target, `text <target>`_,
+//   - named hyperlink references, `text`_ and name_, resolved against the
+//     hyperlink targets and section titles of the document,
+//   - the :doc: and :ref: roles of Sphinx.
+//
+// Anchors are the ids docutils gives section titles and internal targets,
+// whatever the slug style. Unless includeCode is set, literal blocks
+// (indented blocks following a paragraph ending in "::" or a code
+// directive) and inline literals are skipped.
+type rstParser struct {
+	includeCode bool
+}
+
+var (
+	codeDirectiveRegex = regexp.MustCompile(`^\s*\.\. (code|code-block|sourcecode)::`)
+	directiveRegex     = regexp.MustCompile(`^\s*\.\. (?:\|[^|]+\|\s+)?([-\w:]+)::(?:\s+(.*))?$`)
+	targetRegex        = regexp.MustCompile("^\\s*\\.\\. _(?:`([^`]+)`|([^:`]+)):(?:\\s+(.*))?$")
+	inlineLiteralRegex = regexp.MustCompile("``[^`]+``")
+	roleRegex          = regexp.MustCompile(":(doc|ref):`([^`]+)`")
+	otherRoleRegex     = regexp.MustCompile(":[-\\w:]+:`[^`]+`")
+	embeddedRegex      = regexp.MustCompile("`([^`<]*?)\\s*<([^`<>]+)>`(__?)")
+	phraseRefRegex     = regexp.MustCompile("`([^`]+)`(__?)")
+	simpleRefRegex     = regexp.MustCompile(`[A-Za-z0-9](?:[A-Za-z0-9]|[-_.+:][A-Za-z0-9])*__?`)
+	roleTargetRegex    = regexp.MustCompile(`^(?s)(.*?)\s*<([^<>]+)>$`)
+	indirectRegex      = regexp.MustCompile("^(?:`([^`]+)`|([-\\w.+]+))_$")
+)
+
+// rstTarget is a hyperlink target a named reference can refer to.
+type rstTarget struct {
+	// definition is the index of the external target in the definitions of
+	// the document, or -1 for internal targets and section titles.
+	definition int
+	// ref is the name an indirect target refers to.
+	ref string
+}
+
+// rstRef is a named hyperlink reference, resolved once the whole document
+// is parsed.
+type rstRef struct {
+	link Link
+	name string
+}
+
+func (p rstParser) parse(source []byte, _ Slugger) Document {
+	var doc Document
+	targets := map[string]rstTarget{}
+	var refs []rstRef
+	addTarget := func(name string, target rstTarget) {
+		name = normalizeLabel(name)
+		if _, ok := targets[name]; !ok {
+			targets[name] = target
+		}
+	}
+	addLink := func(kind LinkKind, text string, target string, line int, column int) {
+		doc.Links = append(doc.Links, Link{Kind: kind, Text: text, Target: target, Line: line, Column: column})
+	}
+
+	lines := strings.Split(string(source), "\n")
+	inLiteral := false
+	for i, line := range lines {
+		lineNum := i + 1
+		if !p.includeCode {
+			if inLiteral {
+				if strings.TrimSpace(line) == "" || line[0] == ' ' || line[0] == '\t' {
+					continue
+				}
+				inLiteral = false
+			}
+			if codeDirectiveRegex.MatchString(line) || (strings.HasSuffix(strings.TrimSpace(line), "::") && !directiveRegex.MatchString(line)) {
+				inLiteral = true
+			}
+			line = blankInlineLiterals(line)
+		}
+		column := func(offset int) int {
+			return utf8.RuneCountInString(line[:offset]) + 1
+		}
+
+		if title, ok := sectionTitle(lines, i); ok {
+			doc.Anchors = append(doc.Anchors, docutilsID(title))
+			doc.Labels = append(doc.Labels, normalizeLabel(title))
+			addTarget(title, rstTarget{definition: -1})
+		}
+
+		if m := targetRegex.FindStringSubmatchIndex(line); m != nil {
+			name := submatch(line, m, 1) + submatch(line, m, 2)
+			target := strings.TrimSpace(submatch(line, m, 3))
+			switch {
+			case name == "_":
+				// Anonymous targets are not referred to by name
+				if kind, ok := ClassifyLink(target, false); ok {
+					addLink(kind, name, target, lineNum, column(m[0]))
+				}
+			case target == "":
+				// An internal target names the element that follows
+				doc.Anchors = append(doc.Anchors, docutilsID(name))
+				doc.Labels = append(doc.Labels, normalizeLabel(name))
+				addTarget(name, rstTarget{definition: -1})
+			case indirectRegex.MatchString(target):
+				r := indirectRegex.FindStringSubmatch(target)
+				addTarget(name, rstTarget{definition: -1, ref: r[1] + r[2]})
+			default:
+				addTarget(name, rstTarget{definition: len(doc.Definitions)})
+				doc.Definitions = append(doc.Definitions, Definition{Label: name, Target: target, Line: lineNum, Column: column(m[0])})
+				if kind, ok := ClassifyLink(target, false); ok {
+					addLink(kind, name, target, lineNum, column(m[0]))
+				}
+			}
+			continue
+		}
+
+		if m := directiveRegex.FindStringSubmatchIndex(line); m != nil {
+			target := strings.TrimSpace(submatch(line, m, 2))
+			switch submatch(line, m, 1) {
+			case "image", "figure":
+				if kind, ok := ClassifyLink(target, true); ok {
+					addLink(kind, "", target, lineNum, column(m[0]))
+				}
+				continue
+			case "include", "literalinclude":
+				// <name> includes a file of the docutils standard library
+				if !strings.HasPrefix(target, "<") {
+					if kind, ok := ClassifyLink(target, false); ok {
+						addLink(kind, "", target, lineNum, column(m[0]))
+					}
+				}
+				continue
+			}
+		}
+
+		// Roles first, so their content is not taken for references
+		for _, m := range roleRegex.FindAllStringSubmatchIndex(line, -1) {
+			text, target := roleText(submatch(line, m, 2))
+			switch submatch(line, m, 1) {
+			case "doc":
+				if filepath.Ext(target) == "" {
+					target += ".rst"
+				}
+				addLink(FileLink, text, target, lineNum, column(m[0]))
+			case "ref":
+				doc.Refs = append(doc.Refs, Link{Kind: ReferenceLink, Text: text, Target: normalizeLabel(target), Line: lineNum, Column: column(m[0])})
+			}
+		}
+		line = blankMatches(line, roleRegex)
+		line = blankMatches(line, otherRoleRegex)
+
+		for _, m := range embeddedRegex.FindAllStringSubmatchIndex(line, -1) {
+			text, target := submatch(line, m, 1), submatch(line, m, 2)
+			if strings.HasSuffix(target, "_") {
+				// An embedded alias refers to a named target
+				refs = append(refs, rstRef{
+					link: Link{Kind: ReferenceLink, Text: text, Target: target, Line: lineNum, Column: column(m[0])},
+					name: strings.TrimSuffix(strings.Trim(target, "`"), "_"),
+				})
+				continue
+			}
+			if kind, ok := ClassifyLink(target, false); ok {
+				addLink(kind, text, target, lineNum, column(m[0]))
+			}
+			if text != "" && submatch(line, m, 3) == "_" {
+				addTarget(text, rstTarget{definition: -1})
+			}
+		}
+		line = blankMatches(line, embeddedRegex)
+
+		for _, m := range phraseRefRegex.FindAllStringSubmatchIndex(line, -1) {
+			if submatch(line, m, 2) == "_" {
+				text := submatch(line, m, 1)
+				refs = append(refs, rstRef{link: Link{Kind: ReferenceLink, Text: text, Target: text, Line: lineNum, Column: column(m[0])}, name: text})
+			}
+		}
+		line = blankMatches(line, phraseRefRegex)
+
+		for _, m := range simpleRefRegex.FindAllStringIndex(line, -1) {
+			if !simpleRefBoundary(line, m[0], m[1]) || strings.HasSuffix(line[m[0]:m[1]], "__") {
+				continue
+			}
+			text := line[m[0] : m[1]-1]
+			refs = append(refs, rstRef{link: Link{Kind: ReferenceLink, Text: text, Target: text, Line: lineNum, Column: column(m[0])}, name: text})
+		}
+	}
+
+	// Named references may refer to targets further down the document
+	for _, ref := range refs {
+		if !resolveTarget(targets, &doc, normalizeLabel(ref.name)) {
+			doc.Links = append(doc.Links, ref.link)
+		}
+	}
+	return doc
+}
+
+// resolveTarget reports whether the target name is defined, marking the
+// definitions it leads to as used.
+func resolveTarget(targets map[string]rstTarget, doc *Document, name string) bool {
+	for seen := 0; seen <= len(targets); seen++ {
+		target, ok := targets[name]
+		if !ok {
+			return false
+		}
+		if target.definition >= 0 {
+			doc.Definitions[target.definition].Used = true
+		}
+		if target.ref == "" {
+			return true
+		}
+		name = normalizeLabel(target.ref)
+	}
+	// Indirect targets referring to each other
+	return false
+}
+
+// sectionTitle returns the title when line i of lines is the text of a
+// section title, underlined with punctuation at least as long as the text.
+func sectionTitle(lines []string, i int) (string, bool) {
+	text := strings.TrimRight(lines[i], " \t\r")
+	if i+1 >= len(lines) || strings.TrimSpace(text) == "" || isAdornment(text) {
+		return "", false
+	}
+	if text[0] == ' ' || text[0] == '\t' {
+		// Only titles with an overline may be indented
+		if i == 0 || !isAdornment(lines[i-1]) {
+			return "", false
+		}
+	}
+	underline := strings.TrimRight(lines[i+1], " \t\r")
+	if !isAdornment(underline) || utf8.RuneCountInString(underline) < utf8.RuneCountInString(strings.TrimSpace(text)) {
+		return "", false
+	}
+	return strings.TrimSpace(text), true
+}
+
+// isAdornment reports whether line is an underline or overline: a single
+// punctuation character repeated.
+func isAdornment(line string) bool {
+	line = strings.TrimRight(line, " \t\r")
+	if len(line) < 2 || !unicode.IsPunct(rune(line[0])) && !unicode.IsSymbol(rune(line[0])) {
+		return false
+	}
+	return strings.Trim(line, line[:1]) == ""
+}
+
+// roleText splits the content of a role into its text and target, which
+// are the same unless the content has the form "text <target>".
+func roleText(content string) (string, string) {
+	if m := roleTargetRegex.FindStringSubmatch(content); m != nil {
+		return m[1], m[2]
+	}
+	return content, content
+}
+
+// simpleRefBoundary reports whether the simple reference at line[start:end]
+// stands on its own, not being part of a longer word.
+func simpleRefBoundary(line string, start int, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(line[:start])
+		if !unicode.IsSpace(r) && !strings.ContainsRune("([{<'\"", r) {
+			return false
+		}
+	}
+	if end < len(line) {
+		r, _ := utf8.DecodeRuneInString(line[end:])
+		if !unicode.IsSpace(r) && !strings.ContainsRune(".,;:!?)]}>'\"", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// submatch returns group n of the match m in s, or "" when it did not match.
+func submatch(s string, m []int, n int) string {
+	if m[2*n] < 0 {
+		return ""
+	}
+	return s[m[2*n]:m[2*n+1]]
+}
+
+// blankInlineLiterals replaces inline literals with spaces, keeping the
+// columns of the remaining links intact.
+func blankInlineLiterals(line string) string {
+	return blankMatches(line, inlineLiteralRegex)
+}
+
+// blankMatches replaces the matches of regex in line with spaces.
+func blankMatches(line string, regex *regexp.Regexp) string {
+	return regex.ReplaceAllStringFunc(line, func(match string) string {
+		return strings.Repeat(" ", len(match))
+	})
+}
+
+var nonIDRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// docutilsID returns the id docutils gives the element named name in HTML:
+// accents folded to ASCII, other characters turned into hyphens and leading
+// digits and hyphens dropped.
+func docutilsID(name string) string {
+	ascii := strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII {
+			return -1
+		}
+		return r
+	}, norm.NFKD.String(strings.ToLower(name)))
+	id := strings.Trim(nonIDRegex.ReplaceAllString(ascii, "-"), "-")
+	return strings.TrimLeft(id, "0123456789-")
+}
+
+// sphinxRoot returns the source directory of the Sphinx project the
+// directory dir belongs to: the closest directory holding a conf.py, or dir
+// itself when there is none.
+func sphinxRoot(dir string) string {
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, "conf.py")); err == nil {
+			return d
+		}
+		if filepath.Dir(d) == d {
+			return dir
+		}
+	}
+}
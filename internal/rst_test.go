@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestRSTParserSkipsLiterals(t *testing.T) {
+	source := "An example::\n" +
+		"\n" +
+		"    `skipped <https://skipped.example.com>`_\n" +
+		"\n" +
+		"``[literal](#literal)`` and `kept <https://kept.example.com>`_\n"
+	p := rstParser{}
+
+	links := p.parse([]byte(source), NewGitHubSlugger()).Links
+
+	expected := []Link{
+		{Kind: WebLink, Text: "kept", Target: "https://kept.example.com", Line: 5, Column: 29},
+	}
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("Expected links:\n%v\nBut got:\n%v", expected, links)
+	}
+
+	p.includeCode = true
+	if links := p.parse([]byte(source), NewGitHubSlugger()).Links; len(links) != 2 {
+		t.Errorf("Expected 2 links when including code, but got %d: %v", len(links), links)
+	}
+}
+
+func TestRSTParser(t *testing.T) {
+	source := "Title\n" +
+		"=====\n" +
+		"\n" +
+		".. _my-label:\n" +
+		"\n" +
+		".. note::\n" +
+		"\n" +
+		"   Visit `Go <https://go.dev>`_, Python_ and `the docs`_.\n" +
+		"\n" +
+		".. |logo| image:: img/logo.png\n" +
+		".. include:: <isonum.txt>\n" +
+		"See :doc:`other`, :ref:`text <My  Label>` and undefined_.\n" +
+		"\n" +
+		".. _Python: https://www.python.org\n" +
+		".. _the docs: python_\n" +
+		".. _unused: other.rst\n"
+
+	doc := rstParser{}.parse([]byte(source), NewGitHubSlugger())
+
+	expectedLinks := []Link{
+		{Kind: WebLink, Text: "Go", Target: "https://go.dev", Line: 8, Column: 10},
+		{Kind: ImageLink, Target: "img/logo.png", Line: 10, Column: 1},
+		{Kind: FileLink, Text: "other", Target: "other.rst", Line: 12, Column: 5},
+		{Kind: WebLink, Text: "Python", Target: "https://www.python.org", Line: 14, Column: 1},
+		{Kind: FileLink, Text: "unused", Target: "other.rst", Line: 16, Column: 1},
+		{Kind: ReferenceLink, Text: "undefined", Target: "undefined", Line: 12, Column: 47},
+	}
+	if !reflect.DeepEqual(doc.Links, expectedLinks) {
+		t.Errorf("Expected links:\n%v\nBut got:\n%v", expectedLinks, doc.Links)
+	}
+
+	expectedRefs := []Link{{Kind: ReferenceLink, Text: "text", Target: "my label", Line: 12, Column: 19}}
+	if !reflect.DeepEqual(doc.Refs, expectedRefs) {
+		t.Errorf("Expected refs:\n%v\nBut got:\n%v", expectedRefs, doc.Refs)
+	}
+	if !reflect.DeepEqual(doc.Anchors, []string{"title", "my-label"}) || !reflect.DeepEqual(doc.Labels, []string{"title", "my-label"}) {
+		t.Errorf("Expected anchors and labels of the title and target, but got %v and %v", doc.Anchors, doc.Labels)
+	}
+	if len(doc.Definitions) != 2 || !doc.Definitions[0].Used || doc.Definitions[1].Used {
+		t.Errorf("Expected the Python definition to be used and the other not, but got %+v", doc.Definitions)
+	}
+}
+
+func TestDocutilsID(t *testing.T) {
+	tests := map[string]string{
+		"Introduction":        "introduction",
+		"Über die Software!":  "uber-die-software",
+		"2. Getting started":  "getting-started",
+		"my_label":            "my-label",
+		"  spaced   title  ":  "spaced-title",
+		"C++ and Go -- a mix": "c-and-go-a-mix",
+	}
+	for name, expected := range tests {
+		if id := docutilsID(name); id != expected {
+			t.Errorf("Expected id %q for %q, but got %q", expected, name, id)
+		}
+	}
+}
+
+func TestValidateFileRST(t *testing.T) {
+	findings, summary, err := validateFile(context.Background(), "../testfiles/sphinx/index.rst", ".rst", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFindings(t, findings, []expectedFinding{
+		{10, "guide/missing.rst", "broken file link"},
+		{11, "nowhere", "undefined label"},
+		{13, "https://go.dev", "unchecked web link"},
+		{13, "Perl", "undefined reference"},
+		{17, "/missing.png", "broken image file link"},
+		{21, "https://www.python.org", "unchecked web link"},
+		{23, "https://example.com", "unchecked web link"},
+		{23, "https://example.com", "unused reference definition"},
+	})
+	if summary.BrokenLinks() != 4 {
+		t.Errorf("Expected 4 broken links, but got %+v", summary)
+	}
+
+	findings, _, err = validateFile(context.Background(), "../testfiles/sphinx/guide/usage.rst", ".rst", Options{})
+	if err != nil || len(findings) != 0 {
+		t.Errorf("Expected no findings for the guide, but got %v (%v)", findings, err)
+	}
+}
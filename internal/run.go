@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+)
+
+// FileResult is the outcome of validating a single file: its findings, the
+// counts of its links and the error when the file could not be validated.
+type FileResult struct {
+	Path     string
+	Findings []Finding
+	Summary  Summary
+	Err      error
+}
+
+// ValidateFiles validates paths with up to opts.Jobs files in flight, each
+// parsed according to its extension. The result of every file is handed to
+// report in the order of paths, so the report does not depend on the number
+// of jobs. Once ctx is done the web links being checked are canceled and no
+// more files are validated; the remaining ones are reported with the
+// context's error.
+func ValidateFiles(ctx context.Context, paths []string, opts Options, report func(FileResult)) {
+	jobs := opts.jobs()
+	if opts.Documents == nil {
+		opts.Documents = NewDocumentCache(opts)
+	}
+
+	results := make([]chan FileResult, len(paths))
+	for i := range results {
+		results[i] = make(chan FileResult, 1)
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < jobs && i < len(paths); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range next {
+				if err := ctx.Err(); err != nil {
+					results[j] <- FileResult{Path: paths[j], Err: err}
+					continue
+				}
+				findings, summary, err := validateFile(ctx, paths[j], filepath.Ext(paths[j]), opts)
+				results[j] <- FileResult{Path: paths[j], Findings: findings, Summary: summary, Err: err}
+			}
+		}()
+	}
+	go func() {
+		for i := range paths {
+			next <- i
+		}
+		close(next)
+	}()
+
+	for _, result := range results {
+		report(<-result)
+	}
+	wg.Wait()
+}
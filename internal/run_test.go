@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func validateTestFiles(t *testing.T, jobs int) ([]string, string, int) {
+	paths, err := filepath.Glob("../testfiles/*.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var order []string
+	var out bytes.Buffer
+	failed := 0
+	ValidateFiles(context.Background(), paths, Options{OnlyErrors: true, Jobs: jobs}, func(res FileResult) {
+		order = append(order, res.Path)
+		WriteText(&out, res.Findings, false)
+		if res.Err != nil || res.Summary.BrokenLinks() > 0 {
+			failed++
+		}
+	})
+	return order, out.String(), failed
+}
+
+func TestValidateFilesDeterministic(t *testing.T) {
+	order, sequential, failed := validateTestFiles(t, 1)
+	if failed != 2 {
+		t.Errorf("Expected corrupt.md and references.md to fail, but %d files failed", failed)
+	}
+
+	for i := 0; i < 5; i++ {
+		parallelOrder, parallel, parallelFailed := validateTestFiles(t, 8)
+		if parallel != sequential || parallelFailed != failed {
+			t.Errorf("Expected the same report with 8 jobs.\nExpected:\n%s\nBut got:\n%s", sequential, parallel)
+		}
+		for j := range order {
+			if parallelOrder[j] != order[j] {
+				t.Errorf("Expected file %d to be %s, but got %s", j, order[j], parallelOrder[j])
+			}
+		}
+	}
+}
+
+func TestValidateFilesMissingFile(t *testing.T) {
+	var results []FileResult
+	ValidateFiles(context.Background(), []string{"../testfiles/missing.md", "../testfiles/glossary.md"}, Options{Jobs: 2}, func(res FileResult) {
+		results = append(results, res)
+	})
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, but got %d", len(results))
+	}
+	if !os.IsNotExist(results[0].Err) || results[1].Err != nil {
+		t.Errorf("Expected only the missing file to fail, but got %v and %v", results[0].Err, results[1].Err)
+	}
+}
+
+func TestValidateFilesSummary(t *testing.T) {
+	var summary Summary
+	ValidateFiles(context.Background(), []string{"../testfiles/references.md", "../testfiles/glossary.md"}, Options{OnlyErrors: true, Jobs: 2}, func(res FileResult) {
+		summary.Add(res.Summary)
+	})
+
+	if summary.Files != 2 || summary.Links != 8 {
+		t.Errorf("Expected 2 files and 8 links, but got %d files and %d links", summary.Files, summary.Links)
+	}
+	if summary.BrokenLinks() != 1 || summary.Broken[ReferenceLink] != 1 {
+		t.Errorf("Expected 1 broken reference link, but got %v", summary.Broken)
+	}
+
+	var buf bytes.Buffer
+	summary.Write(&buf)
+	expected := "# Files scanned: 2, links checked: 8, broken links: 1\n#   reference: 1\n"
+	if buf.String() != expected {
+		t.Errorf("Expected summary:\n%s\nBut got:\n%s", expected, buf.String())
+	}
+}
+
+func TestValidateFilesMixedFormats(t *testing.T) {
+	paths, err := FindFiles("../testfiles/formats", []string{".md", ".markdown"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("Expected the .md and .markdown files, but got %v", paths)
+	}
+
+	var findings []Finding
+	ValidateFiles(context.Background(), paths, Options{OnlyErrors: true}, func(res FileResult) {
+		if res.Err != nil {
+			t.Errorf("Expected %s to be validated, but got %v", res.Path, res.Err)
+		}
+		findings = append(findings, res.Findings...)
+	})
+
+	expected := []string{"../sphinx/index.rst#outro", "../site/index.html#bottom"}
+	if len(findings) != len(expected) {
+		t.Fatalf("Expected %d broken links, but got %v", len(expected), findings)
+	}
+	for i, target := range expected {
+		if findings[i].Target != target || findings[i].Message != "broken header link" {
+			t.Errorf("Expected a broken header link to %s, but got %v", target, findings[i])
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sarifRules describes the rule every kind of finding is reported under.
+var sarifRules = []struct {
+	kind        LinkKind
+	id          string
+	description string
+}{
+	{FileLink, "broken-file-link", "Links to files must point to an existing file"},
+	{ImageLink, "broken-image-link", "Images must point to an existing file"},
+	{InternalLink, "broken-header-link", "Links to headings must point to an existing file and anchor"},
+	{WebLink, "broken-web-link", "Web links must be reachable"},
+	{ReferenceLink, "reference-link", "Reference links must have a definition and definitions must be used"},
+	{UnusedSuppression, "unused-suppression", "Suppression comments must turn off a finding"},
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                        `json:"tool"`
+	Invocations        []sarifInvocation                `json:"invocations"`
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	ColumnKind         string                           `json:"columnKind"`
+	Results            []sarifResult                    `json:"results"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// sarifSrcRoot is the base id of the paths relative to the root of the
+// repository, which code scanning maps to the files of the checkout.
+const sarifSrcRoot = "%SRCROOT%"
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// sarifReporter writes the errors and warnings of a run as a SARIF 2.1.0
+// log, for code scanning tools. Unchecked web links are left out, files
+// that could not be validated are reported as notifications. Files are
+// located relative to root, the working directory unless set, which is
+// taken to be the root of the repository.
+type sarifReporter struct {
+	w             io.Writer
+	root          string
+	results       []sarifResult
+	notifications []sarifNotification
+}
+
+// artifact returns the location of the file at path: a URI relative to
+// %SRCROOT%, or an absolute file URI for files outside of the root.
+func (r *sarifReporter) artifact(path string) sarifArtifactLocation {
+	root := r.srcRoot()
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return sarifArtifactLocation{URI: filepath.ToSlash(path)}
+	}
+	if rel, err := filepath.Rel(root, abs); err == nil && isAncestor(root, abs) {
+		return sarifArtifactLocation{URI: (&url.URL{Path: filepath.ToSlash(rel)}).EscapedPath(), URIBaseID: sarifSrcRoot}
+	}
+	return sarifArtifactLocation{URI: fileURI(abs)}
+}
+
+// srcRoot returns the directory %SRCROOT% stands for.
+func (r *sarifReporter) srcRoot() string {
+	if r.root == "" {
+		r.root, _ = os.Getwd()
+	}
+	return r.root
+}
+
+// fileURI returns the file URI of the absolute path, like
+// file:///C:/docs/a.md on Windows.
+func fileURI(abs string) string {
+	p := filepath.ToSlash(abs)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+func (r *sarifReporter) File(res FileResult) error {
+	if res.Err != nil {
+		r.notifications = append(r.notifications, sarifNotification{
+			Level:   "error",
+			Message: sarifMessage{Text: res.Err.Error()},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: r.artifact(res.Path),
+			}}},
+		})
+	}
+	for _, f := range res.Findings {
+		if f.Severity == SeverityInfo {
+			continue
+		}
+		index := sarifRuleIndex(f.Kind)
+		level := "error"
+		if f.Severity == SeverityWarning {
+			level = "warning"
+		}
+		message := f.Message + ": " + f.Target
+		if f.Detail != "" {
+			message += " (" + f.Detail + ")"
+		}
+		r.results = append(r.results, sarifResult{
+			RuleID:    sarifRules[index].id,
+			RuleIndex: index,
+			Level:     level,
+			Message:   sarifMessage{Text: message},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: r.artifact(f.File),
+				Region:           &sarifRegion{StartLine: f.Line, StartColumn: f.Column},
+			}}},
+		})
+	}
+	return nil
+}
+
+func (r *sarifReporter) Finish(summary Summary) error {
+	rules := make([]sarifRule, len(sarifRules))
+	for i, rule := range sarifRules {
+		rules[i] = sarifRule{ID: rule.id, ShortDescription: sarifMessage{Text: rule.description}}
+	}
+	results := r.results
+	if results == nil {
+		results = []sarifResult{}
+	}
+
+	enc := json.NewEncoder(r.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "brokenlinks",
+				InformationURI: "https://github.com/erikwj/brokenlinks",
+				Rules:          rules,
+			}},
+			Invocations: []sarifInvocation{{
+				ExecutionSuccessful:        len(r.notifications) == 0,
+				ToolExecutionNotifications: r.notifications,
+			}},
+			OriginalURIBaseIDs: map[string]sarifArtifactLocation{
+				sarifSrcRoot: {URI: strings.TrimSuffix(fileURI(r.srcRoot()), "/") + "/"},
+			},
+			// Columns count runes, not UTF-16 code units
+			ColumnKind: "unicodeCodePoints",
+			Results:    results,
+		}},
+	})
+}
+
+// sarifRuleIndex returns the index of the rule for kind in sarifRules.
+func sarifRuleIndex(kind LinkKind) int {
+	for i, rule := range sarifRules {
+		if rule.kind == kind {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,207 @@
+package internal
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Slugger turns heading texts into anchors. A Slugger is used for a single
+// document: Slug is called for every heading in document order, so that
+// duplicate headings can be given a unique suffix.
+type Slugger interface {
+	Slug(heading string) string
+}
+
+// githubSlugger produces the anchors GitHub generates for headings: lower
+// case, unicode letters and numbers kept, spaces turned into hyphens and all
+// other punctuation and emoji dropped. Duplicates get a -1, -2, ... suffix.
+type githubSlugger struct {
+	seen map[string]int
+}
+
+// NewGitHubSlugger returns a Slugger producing GitHub compatible anchors.
+func NewGitHubSlugger() Slugger {
+	return &githubSlugger{seen: map[string]int{}}
+}
+
+func (s *githubSlugger) Slug(heading string) string {
+	slug := strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '-'
+		case r == '-' || unicode.In(r, unicode.L, unicode.M, unicode.N, unicode.Pc):
+			return r
+		default:
+			return -1
+		}
+	}, strings.ToLower(heading))
+	return uniqueSlug(s.seen, slug, "-")
+}
+
+// gitlabSlugger produces GitLab's anchors. Like GitHub it keeps unicode
+// letters, but it collapses consecutive hyphens, prefixes numeric anchors
+// with "anchor-" and numbers duplicates without checking for collisions.
+type gitlabSlugger struct {
+	seen map[string]int
+}
+
+// NewGitLabSlugger returns a Slugger producing GitLab compatible anchors.
+func NewGitLabSlugger() Slugger {
+	return &gitlabSlugger{seen: map[string]int{}}
+}
+
+var (
+	hyphensRegex = regexp.MustCompile(`-{2,}`)
+	numericRegex = regexp.MustCompile(`^[0-9]+$`)
+)
+
+func (s *gitlabSlugger) Slug(heading string) string {
+	slug := strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '-'
+		case r == '-' || unicode.In(r, unicode.L, unicode.M, unicode.N, unicode.Pc):
+			return r
+		default:
+			return -1
+		}
+	}, strings.ToLower(heading))
+	slug = hyphensRegex.ReplaceAllString(slug, "-")
+	if numericRegex.MatchString(slug) {
+		slug = "anchor-" + slug
+	}
+
+	count := s.seen[slug]
+	s.seen[slug]++
+	if count > 0 {
+		return slug + "-" + strconv.Itoa(count)
+	}
+	return slug
+}
+
+// pythonMarkdownSlugger produces the anchors of the Python-Markdown toc
+// extension, used by MkDocs and Bitbucket: accents are folded to ASCII,
+// other non-ASCII characters dropped, runs of spaces and hyphens turned into
+// a single hyphen and duplicates suffixed with _1, _2, ...
+type pythonMarkdownSlugger struct {
+	prefix string
+	seen   map[string]int
+}
+
+// NewMkDocsSlugger returns a Slugger producing MkDocs compatible anchors.
+func NewMkDocsSlugger() Slugger {
+	return &pythonMarkdownSlugger{seen: map[string]int{}}
+}
+
+// NewBitbucketSlugger returns a Slugger producing Bitbucket compatible
+// anchors, which are prefixed with "markdown-header-".
+func NewBitbucketSlugger() Slugger {
+	return &pythonMarkdownSlugger{prefix: "markdown-header-", seen: map[string]int{}}
+}
+
+var separatorsRegex = regexp.MustCompile(`[-\s]+`)
+
+func (s *pythonMarkdownSlugger) Slug(heading string) string {
+	slug := strings.Map(func(r rune) rune {
+		switch {
+		case r > unicode.MaxASCII:
+			return -1
+		case r == '-' || r == '_' || unicode.IsSpace(r) || unicode.IsLetter(r) || unicode.IsDigit(r):
+			return r
+		default:
+			return -1
+		}
+	}, norm.NFKD.String(heading))
+	slug = s.prefix + separatorsRegex.ReplaceAllString(strings.ToLower(strings.TrimSpace(slug)), "-")
+	if slug == "" {
+		// Python-Markdown never hands out an empty id
+		s.seen[slug] = 0
+	}
+	return uniqueSlug(s.seen, slug, "_")
+}
+
+// hugoSlugger produces the anchors Hugo generates with its default "github"
+// heading id type. Unlike GitHub it trims the heading and drops combining
+// marks.
+type hugoSlugger struct {
+	seen map[string]int
+}
+
+// NewHugoSlugger returns a Slugger producing Hugo compatible anchors.
+func NewHugoSlugger() Slugger {
+	return &hugoSlugger{seen: map[string]int{}}
+}
+
+func (s *hugoSlugger) Slug(heading string) string {
+	slug := strings.Map(func(r rune) rune {
+		switch {
+		case r == '-' || r == ' ':
+			return '-'
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		default:
+			return -1
+		}
+	}, strings.TrimSpace(heading))
+	return uniqueSlug(s.seen, slug, "-")
+}
+
+// slugStyles maps the names accepted by SluggerFor to their constructors.
+var slugStyles = map[string]func() Slugger{
+	"github":    NewGitHubSlugger,
+	"gitlab":    NewGitLabSlugger,
+	"bitbucket": NewBitbucketSlugger,
+	"mkdocs":    NewMkDocsSlugger,
+	"hugo":      NewHugoSlugger,
+}
+
+// SlugStyles returns the names of the supported slug styles.
+func SlugStyles() []string {
+	var names []string
+	for name := range slugStyles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SluggerFor returns the Slugger constructor for a slug style, like
+// "github" or "mkdocs".
+func SluggerFor(style string) (func() Slugger, error) {
+	newSlugger, ok := slugStyles[strings.ToLower(style)]
+	if !ok {
+		return nil, fmt.Errorf("unknown slug style %q, expected one of: %s", style, strings.Join(SlugStyles(), ", "))
+	}
+	return newSlugger, nil
+}
+
+// uniqueSlug returns slug, or slug with the first free numbered suffix when
+// it was seen before in the document.
+func uniqueSlug(seen map[string]int, slug string, sep string) string {
+	unique := slug
+	for {
+		if _, ok := seen[unique]; !ok {
+			break
+		}
+		seen[slug]++
+		unique = slug + sep + strconv.Itoa(seen[slug])
+	}
+	seen[unique] = 0
+	return unique
+}
+
+// unescapeFragment decodes a percent-encoded link fragment, so links to
+// headings with non-ASCII characters match their anchors.
+func unescapeFragment(fragment string) string {
+	if unescaped, err := url.PathUnescape(fragment); err == nil {
+		return unescaped
+	}
+	return fragment
+}
@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+)
+
+// Summary counts the files scanned, the links checked and the broken links
+// by kind.
+type Summary struct {
+	Files  int
+	Links  int
+	Broken map[LinkKind]int
+}
+
+// Add adds the counts of other to s.
+func (s *Summary) Add(other Summary) {
+	s.Files += other.Files
+	s.Links += other.Links
+	if s.Broken == nil {
+		s.Broken = map[LinkKind]int{}
+	}
+	for kind, n := range other.Broken {
+		s.Broken[kind] += n
+	}
+}
+
+// BrokenLinks returns the number of broken links of all kinds.
+func (s Summary) BrokenLinks() int {
+	total := 0
+	for _, n := range s.Broken {
+		total += n
+	}
+	return total
+}
+
+// Write prints the summary, listing only the kinds with broken links.
+func (s Summary) Write(w io.Writer) {
+	fmt.Fprintf(w, "# Files scanned: %d, links checked: %d, broken links: %d\n", s.Files, s.Links, s.BrokenLinks())
+	for _, kind := range []LinkKind{FileLink, ImageLink, InternalLink, ReferenceLink, WebLink} {
+		if n := s.Broken[kind]; n > 0 {
+			fmt.Fprintf(w, "#   %s: %d\n", kind, n)
+		}
+	}
+}
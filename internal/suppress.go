@@ -0,0 +1,194 @@
+package internal
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// Suppression is a comment turning off the findings of some lines:
+//
+//	<!-- brokenlinks-disable-next-line [kind...] -->
+//	<!-- brokenlinks-disable [kind...] -->
+//	<!-- brokenlinks-enable [kind...] -->
+//
+// Without kinds all kinds of links are suppressed.
+type Suppression struct {
+	// Line is the line of the comment.
+	Line int
+	// Directive is the comment without its delimiters, like
+	// "brokenlinks-disable-next-line web".
+	Directive string
+	Kinds     []LinkKind
+	// NextLine is set for brokenlinks-disable-next-line.
+	NextLine bool
+	// EndLine is the line of the brokenlinks-enable ending a region, or 0
+	// when the region lasts until the end of the document.
+	EndLine int
+	// KindEnds holds the lines of the brokenlinks-enable comments naming
+	// kinds, which end the region for those kinds only.
+	KindEnds map[LinkKind]int
+}
+
+// linkKinds are the kinds a region without kinds suppresses.
+var linkKinds = []LinkKind{FileLink, WebLink, InternalLink, ImageLink, ReferenceLink}
+
+// suppressionRegex matches a suppression comment on a line of its own.
+var suppressionRegex = regexp.MustCompile(`^<!--\s*(brokenlinks-(disable-next-line|disable|enable)((?:\s+(?:file|web|internal|image|reference))*))\s*-->$`)
+
+// fenceRegex matches the fence opening or closing a fenced code block.
+var fenceRegex = regexp.MustCompile("^(```+|~~~+)")
+
+// suppressions scans the lines of source for suppression comments. Comments
+// inside fenced code blocks are examples, not suppressions.
+func suppressions(source []byte) []Suppression {
+	var res []Suppression
+	var open []int
+	fence := ""
+	for i, line := range bytes.Split(source, []byte("\n")) {
+		trimmed := strings.TrimSpace(string(line))
+		if m := fenceRegex.FindString(trimmed); m != "" {
+			switch {
+			case fence == "":
+				fence = m
+			case strings.HasPrefix(m, fence) && strings.TrimLeft(trimmed, m[:1]) == "":
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+
+		m := suppressionRegex.FindStringSubmatch(trimmed)
+		if m == nil {
+			continue
+		}
+		var kinds []LinkKind
+		for _, name := range strings.Fields(m[3]) {
+			var kind LinkKind
+			_ = kind.UnmarshalText([]byte(name))
+			kinds = append(kinds, kind)
+		}
+		s := Suppression{Line: i + 1, Directive: strings.Join(strings.Fields(m[1]), " "), Kinds: kinds}
+
+		switch m[2] {
+		case "disable-next-line":
+			s.NextLine = true
+			res = append(res, s)
+		case "disable":
+			open = append(open, len(res))
+			res = append(res, s)
+		case "enable":
+			// Enable ends the open regions for the kinds it names, or all;
+			// the other kinds of a region stay suppressed
+			var still []int
+			for _, j := range open {
+				if res[j].enable(kinds, s.Line) {
+					still = append(still, j)
+				}
+			}
+			open = still
+		}
+	}
+	return res
+}
+
+// enable ends the region for kinds, or all kinds when there are none, at
+// line. It reports whether some of the kinds of the region are still
+// suppressed.
+func (s *Suppression) enable(kinds []LinkKind, line int) bool {
+	regionKinds := s.Kinds
+	if len(regionKinds) == 0 {
+		regionKinds = linkKinds
+	}
+	open := false
+	for _, k := range regionKinds {
+		if _, ended := s.KindEnds[k]; ended {
+			continue
+		}
+		if !includesKind(kinds, k) {
+			open = true
+			continue
+		}
+		if len(kinds) > 0 {
+			if s.KindEnds == nil {
+				s.KindEnds = map[LinkKind]int{}
+			}
+			s.KindEnds[k] = line
+		}
+	}
+	if !open {
+		s.EndLine = line
+	}
+	return open
+}
+
+// includesKind reports whether kinds, where none means all, includes kind.
+func includesKind(kinds []LinkKind, kind LinkKind) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// covers reports whether the suppression turns off finding f.
+func (s Suppression) covers(f Finding) bool {
+	if !includesKind(s.Kinds, f.Kind) {
+		return false
+	}
+	if s.NextLine {
+		return f.Line == s.Line+1
+	}
+	end := s.EndLine
+	if line, ok := s.KindEnds[f.Kind]; ok {
+		end = line
+	}
+	return f.Line > s.Line && (end == 0 || f.Line < end)
+}
+
+// suppress drops the findings turned off by a suppression. Suppressions
+// that turn off nothing are reported as warnings, unless onlyErrors is set.
+func suppress(findings []Finding, sups []Suppression, filePath string, onlyErrors bool) []Finding {
+	if len(sups) == 0 {
+		return findings
+	}
+	used := make([]bool, len(sups))
+	var res []Finding
+	for _, f := range findings {
+		suppressed := false
+		for i, s := range sups {
+			if s.covers(f) {
+				used[i] = true
+				suppressed = true
+			}
+		}
+		if !suppressed {
+			res = append(res, f)
+		}
+	}
+
+	if onlyErrors {
+		return res
+	}
+	for i, s := range sups {
+		if used[i] {
+			continue
+		}
+		res = append(res, Finding{
+			File:     filePath,
+			Line:     s.Line,
+			Column:   1,
+			Target:   s.Directive,
+			Kind:     UnusedSuppression,
+			Severity: SeverityWarning,
+			Message:  "unused suppression",
+		})
+	}
+	return res
+}
@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSuppressions(t *testing.T) {
+	source := []byte("# Title\n" +
+		"<!-- brokenlinks-disable-next-line -->\n" +
+		"[a](a.md)\n" +
+		"<!-- brokenlinks-disable web image -->\n" +
+		"[b](b.md)\n" +
+		"<!-- brokenlinks-enable -->\n" +
+		"```\n" +
+		"<!-- brokenlinks-disable -->\n" +
+		"```\n" +
+		"Not a suppression: `<!-- brokenlinks-disable -->`\n" +
+		"<!-- brokenlinks-disable-next-line nonsense -->\n")
+
+	sups := suppressions(source)
+	if len(sups) != 2 {
+		t.Fatalf("Expected 2 suppressions, but got %+v", sups)
+	}
+	if !sups[0].NextLine || sups[0].Line != 2 || len(sups[0].Kinds) != 0 {
+		t.Errorf("Expected a disable-next-line on line 2, but got %+v", sups[0])
+	}
+	if sups[1].NextLine || sups[1].Line != 4 || sups[1].EndLine != 6 || len(sups[1].Kinds) != 2 || sups[1].Kinds[0] != WebLink {
+		t.Errorf("Expected a web and image region from line 4 to 6, but got %+v", sups[1])
+	}
+	if sups[1].Directive != "brokenlinks-disable web image" {
+		t.Errorf("Expected the directive of the comment, but got %q", sups[1].Directive)
+	}
+}
+
+func TestValidateFileSuppressions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.md")
+	source := "<!-- brokenlinks-disable-next-line -->\n" +
+		"[a](a.md)\n" +
+		"<!-- brokenlinks-disable-next-line file -->\n" +
+		"![b](b.png)\n" +
+		"<!-- brokenlinks-disable image -->\n" +
+		"![c](c.png) [d](d.md)\n" +
+		"<!-- brokenlinks-enable image -->\n" +
+		"![e](e.png)\n" +
+		"<!-- brokenlinks-disable -->\n"
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, summary, err := validateFile(context.Background(), path, ".md", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFindings(t, findings, []expectedFinding{
+		{3, "brokenlinks-disable-next-line file", "unused suppression"},
+		{4, "b.png", "broken image file link"},
+		{6, "d.md", "broken file link"},
+		{8, "e.png", "broken image file link"},
+		{9, "brokenlinks-disable", "unused suppression"},
+	})
+	if findings[0].Severity != SeverityWarning || findings[4].Severity != SeverityWarning {
+		t.Errorf("Expected unused suppressions to be warnings, but got %v", findings)
+	}
+	if summary.BrokenLinks() != 3 {
+		t.Errorf("Expected 3 broken links, but got %+v", summary)
+	}
+
+	findings, _, _ = validateFile(context.Background(), path, ".md", Options{OnlyErrors: true})
+	if len(findings) != 3 {
+		t.Errorf("Expected unused suppressions to be dropped with OnlyErrors, but got %v", findings)
+	}
+}
+
+func TestValidateFileSuppressionsEnableKind(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.md")
+	source := "<!-- brokenlinks-disable -->\n" +
+		"[a](a.md) ![b](b.png)\n" +
+		"<!-- brokenlinks-enable file -->\n" +
+		"[c](c.md) ![d](d.png) [e](#e)\n" +
+		"<!-- brokenlinks-enable -->\n" +
+		"![f](f.png)\n"
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, _, err := validateFile(context.Background(), path, ".md", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only file links are enabled again, until the region ends for all
+	assertFindings(t, findings, []expectedFinding{
+		{4, "c.md", "broken file link"},
+		{6, "f.png", "broken image file link"},
+	})
+}
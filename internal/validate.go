@@ -1,65 +1,185 @@
 package internal
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
-	"io"
+	"runtime"
 	"strings"
 
 	"os"
 	"path/filepath"
-	"regexp"
 )
 
-type DocRegex struct {
-	web      *regexp.Regexp
-	file     *regexp.Regexp
-	internal *regexp.Regexp
-	image    *regexp.Regexp
+// Options controls how links are validated.
+type Options struct {
+	// OnlyErrors drops the findings that are not errors: unchecked web links
+	// and unused reference definitions.
+	OnlyErrors bool
+	// WebChecker checks web links over HTTP. When nil, web links are only
+	// listed as unchecked.
+	WebChecker *WebChecker
+	// IncludeCode also validates links inside code blocks and inline code,
+	// which are skipped by default.
+	IncludeCode bool
+	// NewSlugger creates the Slugger used to compute the anchors of a
+	// document's headings. Defaults to NewGitHubSlugger.
+	NewSlugger func() Slugger
+	// Documents caches parsed documents across the run. When nil, each
+	// ValidateLinks call uses a cache of its own.
+	Documents *DocumentCache
+	// Jobs is the number of files validated concurrently. Defaults to the
+	// number of CPUs.
+	Jobs int
+	// Configs holds the configuration files of the run. When nil, only
+	// the options above apply.
+	Configs *Configs
 }
 
-func ValidateLine(line string, lineNum int, filePath string, regexs DocRegex, onlyErrors bool) error {
-	// Supported links can only have characters or numbers in the name of the link
+func (o Options) newSlugger() func() Slugger {
+	if o.NewSlugger != nil {
+		return o.NewSlugger
+	}
+	return NewGitHubSlugger
+}
+
+// sluggerFor returns the Slugger for the headings of the document at path,
+// which follows the slug style configured for its directory.
+func (o Options) sluggerFor(path string) Slugger {
+	if o.Configs != nil {
+		if cfg, err := o.Configs.For(filepath.Dir(path)); err == nil && cfg.SlugStyle != "" {
+			if newSlugger, err := SluggerFor(cfg.SlugStyle); err == nil {
+				return newSlugger()
+			}
+		}
+	}
+	return o.newSlugger()()
+}
 
-	linksError := validateInternalLinks(os.Stdout, regexs.file.FindAllStringSubmatch(line, -1), filePath, lineNum)
-	imgError := validateImages(os.Stdout, regexs.image.FindAllStringSubmatch(line, -1), filePath, lineNum)
-	webError := validateWebUrls(os.Stdout, regexs.web.FindAllStringSubmatch(line, -1), filePath, lineNum, onlyErrors)
-	internalError := validateInternalReferenceLinks(os.Stdout, regexs.internal.FindAllStringSubmatch(line, -1), filePath, lineNum)
+func (o Options) documents() *DocumentCache {
+	if o.Documents != nil {
+		return o.Documents
+	}
+	return NewDocumentCache(o)
+}
 
-	if linksError != 0 || imgError != 0 || webError != 0 || internalError != 0 {
-		return fmt.Errorf("\u001b[31m# error validating line in file %s:%d\u001b[0m", filePath, lineNum)
+func (o Options) jobs() int {
+	if o.Jobs > 0 {
+		return o.Jobs
+	}
+	return runtime.NumCPU()
+}
+
+// ValidateLine validates the links on a single line and prints the
+// findings to stdout.
+func ValidateLine(line string, lineNum int, filePath string, extension string, opts Options) error {
+	var links []Link
+	for _, link := range fileParser(extension, opts).parse([]byte(line), opts.newSlugger()()).Links {
+		// Definitions may live on any other line of the document, so a
+		// single line cannot tell whether a reference is undefined
+		if link.Kind == ReferenceLink {
+			continue
+		}
+		link.Line = lineNum
+		links = append(links, link)
+	}
+	findings := validateLinks(context.Background(), links, filePath, opts)
+	writeStdout(findings)
+	if countErrors(findings) > 0 {
+		return fmt.Errorf("# error validating line in file %s:%d", filePath, lineNum)
 	}
 	return nil
 }
 
-func validateInternalLinks(w io.Writer, links [][]string, filePath string, lineNum int) int {
+// writeStdout prints findings to stdout, colored when it is a terminal.
+func writeStdout(findings []Finding) {
+	color, _ := UseColor("auto", os.Stdout)
+	WriteText(os.Stdout, findings, color)
+}
+
+// validateLinks validates links found in the file at filePath and returns
+// the findings in the order of the links. ctx cancels the web link checks.
+func validateLinks(ctx context.Context, links []Link, filePath string, opts Options) []Finding {
+	var findings []Finding
+	findings = append(findings, validateInternalLinks(linksOfKind(links, FileLink), filePath)...)
+	findings = append(findings, validateImages(linksOfKind(links, ImageLink), filePath)...)
+	if opts.WebChecker != nil {
+		findings = append(findings, checkWebUrls(ctx, opts.WebChecker, linksOfKind(links, WebLink), filePath)...)
+	} else {
+		findings = append(findings, validateWebUrls(linksOfKind(links, WebLink), filePath, opts.OnlyErrors)...)
+	}
+	findings = append(findings, validateInternalReferenceLinks(linksOfKind(links, InternalLink), filePath, opts.documents())...)
+	findings = append(findings, validateReferences(linksOfKind(links, ReferenceLink), filePath)...)
+	sortFindings(findings)
+	return findings
+}
+
+func linksOfKind(links []Link, kind LinkKind) []Link {
+	var res []Link
 	for _, link := range links {
-		if check_length(link) {
-			continue
+		if link.Kind == kind {
+			res = append(res, link)
+		}
+	}
+	return res
+}
+
+// countErrors returns the number of findings with SeverityError.
+func countErrors(findings []Finding) int {
+	n := 0
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			n++
 		}
-		url := link[2]
-		absPath, err := filepath.Abs(filepath.Dir(filePath))
+	}
+	return n
+}
+
+// linkDir returns the absolute directory relative links in the file at
+// filePath are resolved against.
+func linkDir(filePath string) (string, error) {
+	return filepath.Abs(filepath.Dir(filePath))
+}
+
+// linkPath returns the path target points to from the file at filePath.
+// Like in Sphinx, absolute paths in reStructuredText are relative to the
+// source directory of the project.
+func linkPath(filePath string, target string) (string, error) {
+	dir, err := linkDir(filePath)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(target, "/") && filepath.Ext(filePath) == ".rst" {
+		dir = sphinxRoot(dir)
+	}
+	return filepath.Join(dir, target), nil
+}
+
+func validateInternalLinks(links []Link, filePath string) []Finding {
+	var findings []Finding
+	for _, link := range links {
+		// The fragment of a file without known anchors is not checked
+		file, _, _ := strings.Cut(link.Target, "#")
+		targetPath, err := linkPath(filePath, file)
 		if err != nil {
-			err = fmt.Errorf("\u001b[31m# error getting absolute path for file %s:%v\u001b[0m", filePath, err)
-			fmt.Fprintln(w, err) // Handle the error appropriately
+			finding := newFinding(link, filePath, SeverityError, "error getting absolute path")
+			finding.Detail = err.Error()
+			findings = append(findings, finding)
 			continue
 		}
-		targetPath := filepath.Join(absPath, url)
 		if _, err := os.Stat(targetPath); err != nil {
-			err = fmt.Errorf("\u001b[31m# broken file link in file %s:%d issue: %s\u001b[0m", filePath, lineNum, url)
-			fmt.Fprintln(w, err) // Handle the error appropriately
-			return 1
+			findings = append(findings, newFinding(link, filePath, SeverityError, "broken file link"))
+			continue
 		}
 
 	}
-	return 0
+	return findings
 }
-func validateInternalReferenceLinks(w io.Writer, links [][]string, filePath string, lineNum int) int {
+
+func validateInternalReferenceLinks(links []Link, filePath string, docs *DocumentCache) []Finding {
+	var findings []Finding
 	for _, link := range links {
-		if check_length(link) {
-			continue
-		}
-		url := link[2]
+		url := link.Target
 		parts := strings.Split(url, "#")
 		var header string
 		var targetPath string
@@ -69,11 +189,12 @@ func validateInternalReferenceLinks(w io.Writer, links [][]string, filePath stri
 		// If there is a # in the link, split the link into the path and the header
 
 		// Get the root from the file path
-		absPath, err := filepath.Abs(filepath.Dir(filePath))
+		absPath, err := linkDir(filePath)
 
 		if err != nil {
-			err = fmt.Errorf("\u001b[31m# error getting absolute path for file %s:%v\u001b[0m", filePath, err)
-			fmt.Fprintln(w, err) // Handle the error appropriately
+			finding := newFinding(link, filePath, SeverityError, "error getting absolute path")
+			finding.Detail = err.Error()
+			findings = append(findings, finding)
 			continue
 		}
 		if len(parts) > 1 {
@@ -82,156 +203,203 @@ func validateInternalReferenceLinks(w io.Writer, links [][]string, filePath stri
 			} else {
 				fileName = parts[0]
 			}
-			header = parts[1]
+			header = unescapeFragment(parts[1])
 		}
 		targetPath = filepath.Join(absPath, fileName)
 
 		if _, err := os.Stat(targetPath); err != nil {
-			err = fmt.Errorf("\u001b[31m# broken reference link in file %s:%d issue: %s\u001b[0m", filePath, lineNum, url)
-			fmt.Fprintln(w, err) // Handle the error appropriately
-			return 1
+			findings = append(findings, newFinding(link, filePath, SeverityError, "broken reference link"))
+			continue
+		}
+		if header == "" {
+			// An empty fragment links to the document itself
+			continue
+		}
+		doc, err := docs.Get(targetPath)
+		if errors.Is(err, errNoParser) {
+			// The anchors of the file are not known, it only has to exist
+			continue
 		}
-		headers, err := findHeaders(targetPath)
 		if err != nil {
-			// check if header exists in headers
-			err = fmt.Errorf("\u001b[31m# error getting headers for file %s:%v\u001b[0m", filePath, err)
-			fmt.Fprintln(w, err) // Handle the error appropriately
+			finding := newFinding(link, filePath, SeverityError, "error getting headers")
+			finding.Detail = err.Error()
+			findings = append(findings, finding)
 			continue
 		}
 		headerExists := false
-		for _, h := range headers {
+		for _, h := range doc.Anchors {
 			if h == header {
 				headerExists = true
 				break
 			}
 		}
 		if !headerExists {
-			err = fmt.Errorf("\u001b[31m# broken header link in file %s:%d issue: %s\u001b[0m", filePath, lineNum, url)
-			fmt.Fprintln(w, err) // Handle the error appropriately
-			return 1
+			findings = append(findings, newFinding(link, filePath, SeverityError, "broken header link"))
+			continue
 		}
 
 	}
-	return 0
+	return findings
 }
-func findHeaders(absPath string) ([]string, error) {
-	file, err := os.Open(absPath)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	headerRegex := regexp.MustCompile(`^#{1,6} (.*)$`)
-	var headers []string
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		matches := headerRegex.FindStringSubmatch(line)
-		if len(matches) > 1 {
-			headers = append(headers, convertHeader(matches[1]))
+func validateImages(images []Link, filePath string) []Finding {
+	var findings []Finding
+	for _, link := range images {
+		targetPath, err := linkPath(filePath, link.Target)
+		if err != nil {
+			finding := newFinding(link, filePath, SeverityError, "error getting absolute path for image")
+			finding.Detail = err.Error()
+			findings = append(findings, finding)
+			continue
+		}
+		if _, err := os.Stat(targetPath); err != nil {
+			findings = append(findings, newFinding(link, filePath, SeverityError, "broken image file link"))
+			continue
 		}
 	}
+	return findings
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+// validateWebUrls lists web links as unchecked, unless onlyErrors is set.
+func validateWebUrls(urls []Link, filePath string, onlyErrors bool) []Finding {
+	var findings []Finding
+	for _, link := range urls {
+		if !onlyErrors {
+			findings = append(findings, newFinding(link, filePath, SeverityInfo, "unchecked web link"))
+		}
 	}
-
-	return headers, nil
+	return findings
 }
 
-func convertHeader(header string) string {
-	return strings.ReplaceAll(removeSpecialCharacters(strings.ToLower(header)), " ", "-")
+func checkWebUrls(ctx context.Context, checker *WebChecker, links []Link, filePath string) []Finding {
+	urls := make([]string, len(links))
+	for i, link := range links {
+		urls[i] = link.Target
+	}
+	var findings []Finding
+	for i, res := range checker.Check(ctx, urls) {
+		if res.Status.Broken() {
+			finding := newFinding(links[i], filePath, SeverityError, "broken web link")
+			finding.Detail = res.String()
+			findings = append(findings, finding)
+		}
+	}
+	return findings
 }
 
-func removeSpecialCharacters(input string) string {
-	// Regular expression to match non-alphanumeric characters
-	regex := regexp.MustCompile("[^a-zA-Z0-9 -]+")
-	// Replace non-alphanumeric characters with an empty string
-	cleaned := regex.ReplaceAllString(input, "")
-	return cleaned
+// validateReferences reports reference links whose label is not defined.
+func validateReferences(links []Link, filePath string) []Finding {
+	var findings []Finding
+	for _, link := range links {
+		findings = append(findings, newFinding(link, filePath, SeverityError, "undefined reference"))
+	}
+	return findings
 }
 
-func validateImages(w io.Writer, images [][]string, filePath string, lineNum int) int {
-	for _, link := range images {
-		if check_length(link) {
-			continue
-		}
-		url := link[2]
-		absPath, err := filepath.Abs(filepath.Dir(filePath))
+// validateLabels reports links to labels that no document of the project
+// of the file at filePath defines.
+func validateLabels(refs []Link, filePath string, docs *DocumentCache) []Finding {
+	if len(refs) == 0 {
+		return nil
+	}
+	var findings []Finding
+	dir, err := linkDir(filePath)
+	var labels map[string]bool
+	if err == nil {
+		labels, err = docs.labels(sphinxRoot(dir))
+	}
+	for _, link := range refs {
 		if err != nil {
-			err = fmt.Errorf("\u001b[31m# error getting absolute path for image file %s:%v\u001b[0m", filePath, err)
-			fmt.Fprintln(w, err) // Handle the error appropriately
-			continue
-		}
-		targetPath := filepath.Join(absPath, url)
-		if _, err := os.Stat(targetPath); err != nil {
-			err = fmt.Errorf("\u001b[31m# broken image file link in file %s:%d issue: %s\u001b[0m", filePath, lineNum, url)
-			fmt.Fprintln(w, err) // Handle the error appropriately
-			return 1
+			finding := newFinding(link, filePath, SeverityError, "error getting labels")
+			finding.Detail = err.Error()
+			findings = append(findings, finding)
+		} else if !labels[link.Target] {
+			findings = append(findings, newFinding(link, filePath, SeverityError, "undefined label"))
 		}
 	}
-	return 0
+	return findings
 }
 
-func validateWebUrls(w io.Writer, urls [][]string, filePath string, lineNum int, onlyErrors bool) int {
-	for _, link := range urls {
-		if check_length(link) {
-			continue
-		}
-		url := link[2]
-
-		if !onlyErrors {
-			fmt.Fprintf(w, "open %s # filepath: %s:%d\n", url, filePath, lineNum)
+// validateDefinitions reports link reference definitions no link refers to.
+// Unused definitions are not errors, so they are dropped with onlyErrors.
+func validateDefinitions(defs []Definition, filePath string, onlyErrors bool) []Finding {
+	if onlyErrors {
+		return nil
+	}
+	var findings []Finding
+	for _, def := range defs {
+		if !def.Used {
+			findings = append(findings, Finding{
+				File:     filePath,
+				Line:     def.Line,
+				Column:   def.Column,
+				Text:     def.Label,
+				Target:   def.Target,
+				Kind:     ReferenceLink,
+				Severity: SeverityWarning,
+				Message:  "unused reference definition",
+			})
 		}
 	}
-	return 0
+	return findings
 }
 
-func ValidateLinks(filePath string, extension string, onlyErrors bool) error {
-	file, err := os.Open(filePath)
+// ValidateLinks validates all links of a file and prints the findings to
+// stdout. The returned error reports the number of broken links.
+func ValidateLinks(filePath string, extension string, opts Options) error {
+	findings, _, err := validateFile(context.Background(), filePath, extension, opts)
+	writeStdout(findings)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	if broken := countErrors(findings); broken > 0 {
+		return fmt.Errorf("# %d broken links in file %s", broken, filePath)
+	}
+	return nil
+}
 
-	var validateError error = nil
+// validateFile validates all links of a file. The summary counts the links
+// checked and the broken ones by kind; the error is only set when the file
+// or its configuration could not be read. ctx cancels the web link checks.
+func validateFile(ctx context.Context, filePath string, extension string, opts Options) ([]Finding, Summary, error) {
+	summary := Summary{Files: 1, Broken: map[LinkKind]int{}}
 
-	scanner := bufio.NewScanner(file)
-	lineNum := 0
+	var cfg Config
+	if opts.Configs != nil {
+		var err error
+		if cfg, err = opts.Configs.For(filepath.Dir(filePath)); err != nil {
+			return nil, summary, err
+		}
+		opts.WebChecker = opts.Configs.webChecker(cfg, opts.WebChecker)
+	}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		lineNum++
-		validateError = ValidateLine(line, lineNum, filePath, ExtDocRegex(extension), onlyErrors)
+	// Share one cache between the links of the file at least
+	opts.Documents = opts.documents()
+	doc, err := opts.Documents.get(filePath, fileParser(extension, opts))
+	if err != nil {
+		return nil, summary, err
 	}
 
-	if err := scanner.Err(); err != nil {
-		return err
+	links := cfg.filterLinks(doc.Links)
+	refs := cfg.filterLinks(doc.Refs)
+	findings := validateLinks(ctx, links, filePath, opts)
+	findings = append(findings, validateLabels(refs, filePath, opts.Documents)...)
+	if cfg.checks(ReferenceLink) {
+		findings = append(findings, validateDefinitions(doc.Definitions, filePath, opts.OnlyErrors)...)
 	}
-	return validateError
-}
+	findings = suppress(findings, doc.Suppressions, filePath, opts.OnlyErrors)
+	findings = cfg.applySeverity(findings, opts.OnlyErrors)
+	sortFindings(findings)
 
-// default on markdown
-func ExtDocRegex(extension string) DocRegex {
-	switch extension {
-	case ".rst":
-		return DocRegex{
-			file:     regexp.MustCompile(""), // not supported
-			web:      regexp.MustCompile("`(.*) <(https?://[-%()_.!~*'#;/?:@&=+$,A-Za-z0-9]+)>`_"),
-			image:    regexp.MustCompile(`(::image )(.*.[png|svg|gif])`),
-			internal: regexp.MustCompile(`\[([a-zA-Z0-9 ]+)\]\((#[^)]+|.*\.md#[^)]+)\)`),
-		}
-	default:
-		return DocRegex{
-			file:     regexp.MustCompile(`\[([a-zA-Z0-9 ]+)\]\(([^)]+.md)\)`),
-			web:      regexp.MustCompile(`\[([a-zA-Z0-9 ]+)\]\((https?://[-%()_.!~*'#;/?:@&=+$,A-Za-z0-9]+)\)`),
-			image:    regexp.MustCompile(`!\[(.*)\]\(([^)]+.[png|svg|gif])\)`),
-			internal: regexp.MustCompile(`\[([a-zA-Z0-9 ]+)\]\((#[^)]+|[^)]+\.md\#[^)]+)\)`),
+	summary.Links = len(links) + len(refs)
+	if opts.WebChecker == nil {
+		// Web links are only listed, not checked
+		summary.Links -= len(linksOfKind(links, WebLink))
+	}
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			summary.Broken[f.Kind]++
 		}
 	}
-}
-
-func check_length(arr []string) bool {
-	return len(arr) != 3
+	return findings, summary, nil
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -11,7 +12,7 @@ func TestValidateConversion(t *testing.T) {
 	header := "This header's title has lots of words"
 	expected := "this-headers-title-has-lots-of-words"
 
-	res := convertHeader(header)
+	res := NewGitHubSlugger().Slug(header)
 
 	// Assert the expected result
 	if res != expected {
@@ -22,7 +23,7 @@ func TestValidateConversion2(t *testing.T) {
 	header := "Load-balancing algorithms"
 	expected := "load-balancing-algorithms"
 
-	res := convertHeader(header)
+	res := NewGitHubSlugger().Slug(header)
 
 	// Assert the expected result
 	if res != expected {
@@ -30,87 +31,29 @@ func TestValidateConversion2(t *testing.T) {
 	}
 }
 
-func TestValidateRegexFileMd(t *testing.T) {
-	// Prepare the test data
-	regexs := ExtDocRegex(".md")
-	line := " asdfas df [glossary](../testfiles/glossary.md) or a [correct](../testfiles/correct.md), ... ![image](../img/glossary.png) and [corrupt](../testfiles/corrupt.md)"
-
-	data := regexs.file.FindAllStringSubmatch(line, -1)
-	fmt.Println(data)
-	// Assert the expected result
-	if len(data) != 3 {
-		t.Errorf("Expected non empty array of length 3, but got %d", len(data))
-	}
-}
-
-func TestValidateRegexImageMd(t *testing.T) {
-	// Prepare the test data
-	regexs := ExtDocRegex(".md")
-	line := " asdfas df [glossary](../testfiles/glossary.md) or a [correct](../testfiles/correct.md), ... ![image](../img/glossary.png) and [corrupt](../testfiles/corrupt.md)"
-
-	data := regexs.image.FindAllStringSubmatch(line, -1)
-	fmt.Println(data)
-	// Assert the expected result
-	if len(data) != 1 {
-		t.Errorf("Expected non empty array of length 1, but got %d", len(data))
-	}
-	if data[0][2] != "../img/glossary.png" {
-		t.Errorf("Expected link to image but got %s", data[0][2])
-	}
-}
-
-func TestValidateRegexWebMd(t *testing.T) {
-	// Prepare the test data
-	regexs := ExtDocRegex(".md")
-	line := " asdfas df [glossary](../testfiles/glossary.md) or a [correct](../testfiles/correct.md), ... [glossary](../testfiles/glossary.md) and [corrupt](../testfiles/corrupt.md)"
-
-	data := regexs.web.FindAllStringSubmatch(line, -1)
-	fmt.Println(data)
-	// Assert the expected result
-	if len(data) != 0 {
-		t.Errorf("Expected empty array, but got %d", len(data))
-	}
-}
-
-func TestValidateRegexInternalMd(t *testing.T) {
-	// Prepare the test data
-	regexs := ExtDocRegex(".md")
-	line := " To illustrate, if fixed [retry strategy](../abc/01-03-0002-retry-strategy.md) or a [Retry budget](../../glossary/terms/retry-budget.md). Alternatively, it may implement an [exponential backoff](../d/file.md#Exponential-Backoff), where ... "
-
-	data := regexs.internal.FindAllStringSubmatch(line, -1)
-	fmt.Println(data)
-	// Assert the expected result
-	if len(data) != 1 {
-		t.Errorf("Expected non empty array of length 1, but got %d", len(data))
-	}
-	if data[0][2] != "../d/file.md#Exponential-Backoff" {
-		t.Errorf("Expected link to internal reference but got %s", data[0][2])
-	}
-}
-
 func TestValidateWebUrls(t *testing.T) {
 	// Prepare the test data
 	var buf bytes.Buffer
-	urls := [][]string{
-		{"", "", "http://example.com"},
-		{"", "", "http://google.com"},
-		{"", "", "http://github.com"},
+	urls := []Link{
+		{Kind: WebLink, Target: "http://example.com", Line: 1},
+		{Kind: WebLink, Target: "http://google.com", Line: 1},
+		{Kind: WebLink, Target: "http://github.com", Line: 1},
 	}
 	filePath := "/path/to/file.md"
-	lineNum := 1
 
 	// Call the function being tested
-	result := validateWebUrls(&buf, urls, filePath, lineNum, false)
+	findings := validateWebUrls(urls, filePath, false)
+	WriteText(&buf, findings, true)
 
-	// Assert the expected result
-	if result != 0 {
-		t.Errorf("Expected result to be 0, but got %d", result)
+	// Assert the expected result: web links are listed, not broken
+	if countErrors(findings) != 0 || len(findings) != 3 {
+		t.Errorf("Expected 3 unchecked web links, but got %v", findings)
 	}
 
 	// Assert the expected output
-	expectedOutput := "open http://example.com # filepath: /path/to/file.md linenumber: 1\n" +
-		"open http://google.com # filepath: /path/to/file.md linenumber: 1\n" +
-		"open http://github.com # filepath: /path/to/file.md linenumber: 1\n"
+	expectedOutput := "open http://example.com # filepath: /path/to/file.md:1\n" +
+		"open http://google.com # filepath: /path/to/file.md:1\n" +
+		"open http://github.com # filepath: /path/to/file.md:1\n"
 	if buf.String() != expectedOutput {
 		t.Errorf("Unexpected output.\nExpected:\n%s\nGot:\n%s", expectedOutput, buf.String())
 	}
@@ -118,16 +61,17 @@ func TestValidateWebUrls(t *testing.T) {
 func TestValidateSilentWebUrls(t *testing.T) {
 	// Prepare the test data
 	var buf bytes.Buffer
-	urls := [][]string{
-		{"", "", "http://example.com"},
-		{"", "", "http://google.com"},
-		{"", "", "http://github.com"},
+	urls := []Link{
+		{Kind: WebLink, Target: "http://example.com", Line: 1},
+		{Kind: WebLink, Target: "http://google.com", Line: 1},
+		{Kind: WebLink, Target: "http://github.com", Line: 1},
 	}
 	filePath := "/path/to/file.md"
-	lineNum := 1
 
 	// Call the function being tested
-	result := validateWebUrls(&buf, urls, filePath, lineNum, true)
+	findings := validateWebUrls(urls, filePath, true)
+	WriteText(&buf, findings, true)
+	result := len(findings)
 
 	// Assert the expected result
 	if result != 0 {
@@ -144,16 +88,18 @@ func TestValidateSilentWebUrls(t *testing.T) {
 func TestValidateInternalLinks(t *testing.T) {
 	// Prepare the test data
 	var buf bytes.Buffer
-	links := [][]string{
-		{"link1", "description1", "../testfiles/correct.md"},
-		{"link1", "description1", "../testfiles/glossary.md"},
-		{"link1", "description1", "../testfiles/corrupt.md"},
+	lineNum := 10
+	links := []Link{
+		{Text: "description1", Target: "../testfiles/correct.md", Line: lineNum},
+		{Text: "description1", Target: "../testfiles/glossary.md", Line: lineNum},
+		{Text: "description1", Target: "../testfiles/corrupt.md", Line: lineNum},
 	}
 	filePath := "./"
-	lineNum := 10
 
 	// Call the function being tested
-	result := validateInternalLinks(&buf, links, filePath, lineNum)
+	findings := validateInternalLinks(links, filePath)
+	WriteText(&buf, findings, true)
+	result := len(findings)
 
 	// Assert the expected result
 	if result != 0 {
@@ -174,25 +120,30 @@ func TestValidateInternalLinksFailure(t *testing.T) {
 	url2 := "../testfiles/lost.md"
 	url3 := "../testfiles/gone.md"
 
-	links := [][]string{
-		{"link1", "description1", url},
-		{"link1", "description1", url2},
-		{"link1", "description1", url3},
+	lineNum := 1
+	links := []Link{
+		{Text: "description1", Target: url, Line: lineNum},
+		{Text: "description1", Target: url2, Line: lineNum},
+		{Text: "description1", Target: url3, Line: lineNum},
 	}
 	filePath := "./"
-	lineNum := 1
 
 	// Call the function being tested
-	result := validateInternalLinks(&buf, links, filePath, lineNum)
+	findings := validateInternalLinks(links, filePath)
+	WriteText(&buf, findings, true)
+	result := len(findings)
 
 	// Assert the expected result
-	if result != 1 {
-		t.Errorf("Expected validateInternalLinks to return 1, but got %d", result)
+	if result != 3 {
+		t.Errorf("Expected validateInternalLinks to return 3, but got %d", result)
 	}
 
 	// Assert the output written to the writer
-	// function stops after first broken link
-	expectedOutput := fmt.Sprintf("\u001b[31m# broken file link in file %s:%d issue: %s\u001b[0m\n", filePath, lineNum, url)
+	// every broken link is reported
+	expectedOutput := ""
+	for _, link := range links {
+		expectedOutput += fmt.Sprintf("\u001b[31m# broken file link in file %s:%d issue: %s\u001b[0m\n", filePath, lineNum, link.Target)
+	}
 
 	if buf.String() != expectedOutput {
 		t.Errorf("Expected output:\n%s\nBut got:\n%s", expectedOutput, buf.String())
@@ -202,16 +153,18 @@ func TestValidateInternalLinksFailure(t *testing.T) {
 func TestValidateImageLinks(t *testing.T) {
 	// Prepare the test data
 	var buf bytes.Buffer
-	links := [][]string{
-		{"link1", "img1", "../testfiles/img/btn.gif"},
-		{"link2", "img2", "../testfiles/img/btn.png"},
-		{"link3", "img3", "../testfiles/img/btn.svg"},
+	lineNum := 10
+	links := []Link{
+		{Text: "img1", Target: "../testfiles/img/btn.gif", Line: lineNum},
+		{Text: "img2", Target: "../testfiles/img/btn.png", Line: lineNum},
+		{Text: "img3", Target: "../testfiles/img/btn.svg", Line: lineNum},
 	}
 	filePath := "../testfiles/correct.md"
-	lineNum := 10
 
 	// Call the function being tested
-	result := validateImages(&buf, links, filePath, lineNum)
+	findings := validateImages(links, filePath)
+	WriteText(&buf, findings, true)
+	result := len(findings)
 
 	// Assert the expected result 0 == succes; 1 == failure
 	if result != 0 {
@@ -228,24 +181,29 @@ func TestValidateImageLinks(t *testing.T) {
 func TestValidateImageLinksFailure(t *testing.T) {
 	// Prepare the test data
 	var buf bytes.Buffer
-	links := [][]string{
-		{"link1", "img1", "../testfiles/img/btn.gaf"},
-		{"link2", "img2", "../testfiles/img/btn.pnf"},
-		{"link3", "img3", "../testfiles/img/btn.svf"},
+	lineNum := 10
+	links := []Link{
+		{Text: "img1", Target: "../testfiles/img/btn.gaf", Line: lineNum},
+		{Text: "img2", Target: "../testfiles/img/btn.pnf", Line: lineNum},
+		{Text: "img3", Target: "../testfiles/img/btn.svf", Line: lineNum},
 	}
 	filePath := "../testfiles/correct.md"
-	lineNum := 10
 
 	// Call the function being tested
-	result := validateImages(&buf, links, filePath, lineNum)
+	findings := validateImages(links, filePath)
+	WriteText(&buf, findings, true)
+	result := len(findings)
 
-	// Assert the expected result 0 == succes; 1 == failure
-	if result != 1 {
-		t.Errorf("Expected validateInternalLinks to return 1, but got %d", result)
+	// Assert the expected result: the number of broken links
+	if result != 3 {
+		t.Errorf("Expected validateInternalLinks to return 3, but got %d", result)
 	}
 
 	// Assert the output written to the writer
-	expectedOutput := fmt.Sprintf("\u001b[31m# broken image file link in file %s:%d issue: %s\u001b[0m\n", filePath, lineNum, links[0][2])
+	expectedOutput := ""
+	for _, link := range links {
+		expectedOutput += fmt.Sprintf("\u001b[31m# broken image file link in file %s:%d issue: %s\u001b[0m\n", filePath, lineNum, link.Target)
+	}
 
 	if buf.String() != expectedOutput {
 		t.Errorf("Expected output:\n%s\nBut got:\n%s", expectedOutput, buf.String())
@@ -255,15 +213,17 @@ func TestValidateImageLinksFailure(t *testing.T) {
 func TestValidateInternalReferenceLinks(t *testing.T) {
 	// Prepare the test data
 	var buf bytes.Buffer
-	links := [][]string{
-		{"link1", "egg", "./subdir/bla.md#headers-2-with-extra-text"},
-		{"link1", "find me", "#find-me"},
+	lineNum := 13
+	links := []Link{
+		{Text: "egg", Target: "./subdir/bla.md#headers-2-with-extra-text", Line: lineNum},
+		{Text: "find me", Target: "#find-me", Line: lineNum},
 	}
 	filePath := "../testfiles/correct.md"
-	lineNum := 13
 
 	// Call the function being tested
-	result := validateInternalReferenceLinks(&buf, links, filePath, lineNum)
+	findings := validateInternalReferenceLinks(links, filePath, NewDocumentCache(Options{}))
+	WriteText(&buf, findings, true)
+	result := len(findings)
 
 	// Assert the expected result
 	if result != 0 {
@@ -280,37 +240,40 @@ func TestValidateInternalReferenceLinks(t *testing.T) {
 func TestValidateInternalReferenceLinksFailure(t *testing.T) {
 	// Prepare the test data
 	var buf bytes.Buffer
-	links := [][]string{
-		{"link1", "egg", "./subdir/bla.md#header-with-extra-text"},
-		{"link1", "find me", "#found-me"},
+	lineNum := 13
+	links := []Link{
+		{Text: "egg", Target: "./subdir/bla.md#header-with-extra-text", Line: lineNum},
+		{Text: "find me", Target: "#found-me", Line: lineNum},
 	}
 	filePath := "../testfiles/correct.md"
-	lineNum := 13
 
 	// Call the function being tested
-	result := validateInternalReferenceLinks(&buf, links, filePath, lineNum)
+	findings := validateInternalReferenceLinks(links, filePath, NewDocumentCache(Options{}))
+	WriteText(&buf, findings, true)
+	result := len(findings)
 
 	// Assert the expected result
-	if result != 1 {
-		t.Errorf("Expected validateInternalLinks to return 1, but got %d", result)
+	if result != 2 {
+		t.Errorf("Expected validateInternalLinks to return 2, but got %d", result)
 	}
 
 	// Assert the output written to the writer
-	log := fmt.Sprintf("\u001b[31m# broken header link in file %s:%d issue: %s\u001b[0m\n", filePath, lineNum, links[0][2])
-	expectedOutput := log
+	expectedOutput := fmt.Sprintf("\u001b[31m# broken header link in file %s:%d issue: %s\u001b[0m\n", filePath, lineNum, links[0].Target) +
+		fmt.Sprintf("\u001b[31m# broken header link in file %s:%d issue: %s\u001b[0m\n", filePath, lineNum, links[1].Target)
 
 	if buf.String() != expectedOutput {
 		t.Errorf("Expected output:\n%s\nBut got:\n%s", expectedOutput, buf.String())
 	}
 }
 
-func TestFindHeaders(t *testing.T) {
+func TestDocumentAnchors(t *testing.T) {
 	absPath := "../testfiles/subdir/bla.md"
 
-	headers, err := findHeaders(absPath)
+	doc, err := NewDocumentCache(Options{}).Get(absPath)
+	headers := doc.Anchors
 
 	if err != nil {
-		t.Errorf("Expected FindHeaders to pass, but it failed with error: %v", err)
+		t.Errorf("Expected the document to be parsed, but it failed with error: %v", err)
 	}
 
 	expectedHeaders := []string{"title-of-bla", "headers-2-with-extra-text", "level-6-header"}
@@ -332,3 +295,220 @@ func TestInternalReference(t *testing.T) {
 		t.Errorf("Expected header to be found but got error: %v", err)
 	}
 }
+
+func TestValidateReferences(t *testing.T) {
+	var buf bytes.Buffer
+	lineNum := 6
+	links := []Link{
+		{Kind: ReferenceLink, Text: "missing one", Target: "nowhere", Line: lineNum},
+	}
+	filePath := "../testfiles/references.md"
+
+	findings := validateReferences(links, filePath)
+	WriteText(&buf, findings, true)
+	result := len(findings)
+
+	if result != 1 {
+		t.Errorf("Expected validateReferences to return 1, but got %d", result)
+	}
+	expectedOutput := fmt.Sprintf("\u001b[31m# undefined reference in file %s:%d issue: %s\u001b[0m\n", filePath, lineNum, "nowhere")
+	if buf.String() != expectedOutput {
+		t.Errorf("Expected output:\n%s\nBut got:\n%s", expectedOutput, buf.String())
+	}
+}
+
+func TestValidateDefinitions(t *testing.T) {
+	var buf bytes.Buffer
+	defs := []Definition{
+		{Label: "gloss", Target: "glossary.md", Line: 8, Used: true},
+		{Label: "unused", Target: "./gone.md", Line: 13},
+	}
+	filePath := "../testfiles/references.md"
+
+	WriteText(&buf, validateDefinitions(defs, filePath, false), true)
+
+	expectedOutput := fmt.Sprintf("\u001b[33m# unused reference definition in file %s:%d issue: %s\u001b[0m\n", filePath, 13, "./gone.md")
+	if buf.String() != expectedOutput {
+		t.Errorf("Expected output:\n%s\nBut got:\n%s", expectedOutput, buf.String())
+	}
+
+	if findings := validateDefinitions(defs, filePath, true); len(findings) != 0 {
+		t.Errorf("Expected no findings with onlyErrors, but got %v", findings)
+	}
+}
+
+func TestGitHubSlugger(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"This header's title has lots of words", "this-headers-title-has-lots-of-words"},
+		{"Über uns", "über-uns"},
+		{"Emoji 🚀 rocket", "emoji--rocket"},
+		{"snake_case and kebab-case", "snake_case-and-kebab-case"},
+		{"What's new? (v2.0)", "whats-new-v20"},
+		{"日本語", "日本語"},
+	}
+
+	for _, tt := range tests {
+		if res := NewGitHubSlugger().Slug(tt.header); res != tt.expected {
+			t.Errorf("Expected slug '%s' for '%s', but got '%s'", tt.expected, tt.header, res)
+		}
+	}
+}
+
+func TestGitHubSluggerDuplicates(t *testing.T) {
+	slugger := NewGitHubSlugger()
+	headers := []string{"Setup", "Setup", "Setup-1", "Setup"}
+	expected := []string{"setup", "setup-1", "setup-1-1", "setup-2"}
+
+	for i, header := range headers {
+		if res := slugger.Slug(header); res != expected[i] {
+			t.Errorf("Expected slug '%s' for heading %d, but got '%s'", expected[i], i, res)
+		}
+	}
+}
+
+func TestValidateDuplicateHeaderLinks(t *testing.T) {
+	var buf bytes.Buffer
+	lineNum := 13
+	links := []Link{
+		{Text: "the first setup", Target: "#setup", Line: lineNum},
+		{Text: "the second", Target: "#setup-1", Line: lineNum},
+		{Text: "the rocket", Target: "duplicates.md#setup-", Line: lineNum},
+		{Text: "über uns", Target: "#%C3%BCber-uns", Line: lineNum},
+	}
+	filePath := "../testfiles/duplicates.md"
+
+	findings := validateInternalReferenceLinks(links, filePath, NewDocumentCache(Options{}))
+	WriteText(&buf, findings, true)
+	result := len(findings)
+
+	if result != 0 {
+		t.Errorf("Expected validateInternalReferenceLinks to return 0, but got %d:\n%s", result, buf.String())
+	}
+}
+
+func TestSlugStyles(t *testing.T) {
+	// Each row documents the anchor every dialect generates for a heading
+	tests := []struct {
+		header    string
+		github    string
+		gitlab    string
+		bitbucket string
+		mkdocs    string
+		hugo      string
+	}{
+		{"Load-balancing algorithms", "load-balancing-algorithms", "load-balancing-algorithms", "markdown-header-load-balancing-algorithms", "load-balancing-algorithms", "load-balancing-algorithms"},
+		{"This header's title has lots of words", "this-headers-title-has-lots-of-words", "this-headers-title-has-lots-of-words", "markdown-header-this-headers-title-has-lots-of-words", "this-headers-title-has-lots-of-words", "this-headers-title-has-lots-of-words"},
+		{"Über uns", "über-uns", "über-uns", "markdown-header-uber-uns", "uber-uns", "über-uns"},
+		{"Emoji 🚀 rocket", "emoji--rocket", "emoji-rocket", "markdown-header-emoji-rocket", "emoji-rocket", "emoji--rocket"},
+		{"A - B", "a---b", "a-b", "markdown-header-a-b", "a-b", "a---b"},
+		{"snake_case", "snake_case", "snake_case", "markdown-header-snake_case", "snake_case", "snake_case"},
+		{"2024", "2024", "anchor-2024", "markdown-header-2024", "2024", "2024"},
+		{" padded ", "-padded-", "-padded-", "markdown-header-padded", "padded", "padded"},
+	}
+
+	for _, tt := range tests {
+		expected := map[string]string{
+			"github":    tt.github,
+			"gitlab":    tt.gitlab,
+			"bitbucket": tt.bitbucket,
+			"mkdocs":    tt.mkdocs,
+			"hugo":      tt.hugo,
+		}
+		for style, slug := range expected {
+			newSlugger, err := SluggerFor(style)
+			if err != nil {
+				t.Fatalf("Expected slug style %s to exist, but got error: %v", style, err)
+			}
+			if res := newSlugger().Slug(tt.header); res != slug {
+				t.Errorf("Expected %s slug '%s' for '%s', but got '%s'", style, slug, tt.header, res)
+			}
+		}
+	}
+}
+
+func TestSlugStylesDuplicates(t *testing.T) {
+	tests := []struct {
+		style    string
+		expected []string
+	}{
+		{"github", []string{"setup", "setup-1", "setup-2"}},
+		{"gitlab", []string{"setup", "setup-1", "setup-2"}},
+		{"bitbucket", []string{"markdown-header-setup", "markdown-header-setup_1", "markdown-header-setup_2"}},
+		{"mkdocs", []string{"setup", "setup_1", "setup_2"}},
+		{"hugo", []string{"setup", "setup-1", "setup-2"}},
+	}
+
+	for _, tt := range tests {
+		newSlugger, _ := SluggerFor(tt.style)
+		slugger := newSlugger()
+		for i, expected := range tt.expected {
+			if res := slugger.Slug("Setup"); res != expected {
+				t.Errorf("Expected %s slug '%s' for duplicate %d, but got '%s'", tt.style, expected, i, res)
+			}
+		}
+	}
+}
+
+func TestSluggerForUnknownStyle(t *testing.T) {
+	if _, err := SluggerFor("confluence"); err == nil {
+		t.Errorf("Expected an error for an unknown slug style")
+	}
+}
+
+func TestDocumentAnchorsExplicit(t *testing.T) {
+	absPath := "../testfiles/anchors.md"
+
+	doc, err := NewDocumentCache(Options{}).Get(absPath)
+	headers := doc.Anchors
+
+	if err != nil {
+		t.Errorf("Expected the document to be parsed, but it failed with error: %v", err)
+	}
+
+	expectedHeaders := []string{"explicit-anchors", "named-anchor", "inline-id", "block-id", "custom", "custom-classes"}
+	if !reflect.DeepEqual(headers, expectedHeaders) {
+		t.Errorf("Expected headers %v, but got %v", expectedHeaders, headers)
+	}
+}
+
+func TestDocumentAnchorsAllHeadingForms(t *testing.T) {
+	absPath := "../testfiles/headings.md"
+
+	doc, err := NewDocumentCache(Options{}).Get(absPath)
+	headers := doc.Anchors
+
+	if err != nil {
+		t.Errorf("Expected the document to be parsed, but it failed with error: %v", err)
+	}
+
+	expectedHeaders := []string{"setext-title", "setext-section", "closing-hashes", "indented-heading", "a-linked-code-heading", "setext-id"}
+	if !reflect.DeepEqual(headers, expectedHeaders) {
+		t.Errorf("Expected headers %v, but got %v", expectedHeaders, headers)
+	}
+}
+
+// expectedFinding is a finding a test expects, by its line, target and
+// message.
+type expectedFinding struct {
+	line    int
+	target  string
+	message string
+}
+
+// assertFindings fails the test unless findings are the expected ones, in
+// the same order.
+func assertFindings(t *testing.T, findings []Finding, expected []expectedFinding) {
+	t.Helper()
+	if len(findings) != len(expected) {
+		t.Fatalf("Expected %d findings, but got %d: %v", len(expected), len(findings), findings)
+	}
+	for i, e := range expected {
+		f := findings[i]
+		if f.Line != e.line || f.Target != e.target || f.Message != e.message {
+			t.Errorf("Expected %s %s on line %d, but got %v", e.message, e.target, e.line, f)
+		}
+	}
+}
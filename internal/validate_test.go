@@ -7,7 +7,6 @@ import (
 )
 
 var ext = ".md"
-var regexs = internal.ExtDocRegex(ext)
 
 func TestValidateWebLine(t *testing.T) {
 	line := "[GitHub](http://github.com) (and some extra text) [Gitlab](http://gitlab.com) "
@@ -16,7 +15,7 @@ func TestValidateWebLine(t *testing.T) {
 
 	// Test your validateLine function here
 	// with the given line, lineNum, and filePath variables as input
-	err := internal.ValidateLine(line, lineNum, filePath, regexs, false)
+	err := internal.ValidateLine(line, lineNum, filePath, ext, internal.Options{})
 
 	// Assert the expected result
 	if err != nil {
@@ -28,10 +27,9 @@ func TestValidateWebLineRst(t *testing.T) {
 	line := "Table definitions may be constructed either from scratch (check out `the syntax <https://nightlies.apache.org/flink/flink-docs-release-1.17/docs/dev/table/sql/create/#create-table>`_)"
 	lineNum := 1
 	filePath := "/path/to/file.md"
-	r := internal.ExtDocRegex(".rst")
 	// Test your validateLine function here
 	// with the given line, lineNum, and filePath variables as input
-	err := internal.ValidateLine(line, lineNum, filePath, r, false)
+	err := internal.ValidateLine(line, lineNum, filePath, ".rst", internal.Options{})
 
 	// Assert the expected result
 	if err != nil {
@@ -46,7 +44,7 @@ func TestValidateFileLine(t *testing.T) {
 
 	// Test your validateLine function here
 	// with the given line, lineNum, and filePath variables as input
-	err := internal.ValidateLine(line, lineNum, filePath, regexs, false)
+	err := internal.ValidateLine(line, lineNum, filePath, ext, internal.Options{})
 
 	// Assert the expected result
 	if err != nil {
@@ -61,7 +59,7 @@ func TestValidateLongFileLine(t *testing.T) {
 
 	// Test your validateLine function here
 	// with the given line, lineNum, and filePath variables as input
-	err := internal.ValidateLine(line, lineNum, filePath, regexs, false)
+	err := internal.ValidateLine(line, lineNum, filePath, ext, internal.Options{})
 
 	// Assert the expected result
 	if err != nil {
@@ -76,7 +74,7 @@ func TestValidateImgLine(t *testing.T) {
 
 	// Test your validateLine function here
 	// with the given line, lineNum, and filePath variables as input
-	err := internal.ValidateLine(line, lineNum, filePath, regexs, false)
+	err := internal.ValidateLine(line, lineNum, filePath, ext, internal.Options{})
 
 	// Assert the expected result
 	if err != nil {
@@ -85,13 +83,12 @@ func TestValidateImgLine(t *testing.T) {
 }
 
 func TestValidateImgLineRst(t *testing.T) {
-	line := " asldfa fasdf lsd ::image ../testfiles/img/btn.png"
+	line := ".. image:: ../testfiles/img/btn.png"
 	lineNum := 1
 	filePath := "./"
-	r := internal.ExtDocRegex(".rst")
 	// Test your validateLine function here
 	// with the given line, lineNum, and filePath variables as input
-	err := internal.ValidateLine(line, lineNum, filePath, r, false)
+	err := internal.ValidateLine(line, lineNum, filePath, ".rst", internal.Options{})
 
 	// Assert the expected result
 	if err != nil {
@@ -106,7 +103,7 @@ func TestValidateFileLineFail(t *testing.T) {
 	filePath := "/path/to/file.md"
 
 	// Test your validateLine function here
-	err := internal.ValidateLine(line, lineNum, filePath, regexs, false)
+	err := internal.ValidateLine(line, lineNum, filePath, ext, internal.Options{})
 
 	// Assert that the function fails
 	if err == nil {
@@ -121,7 +118,7 @@ func TestValidateImageLineFail(t *testing.T) {
 	filePath := "/path/to/file.md"
 
 	// Test your validateLine function here
-	err := internal.ValidateLine(line, lineNum, filePath, regexs, false)
+	err := internal.ValidateLine(line, lineNum, filePath, ext, internal.Options{})
 
 	// Assert that the function fails
 	if err == nil {
@@ -131,13 +128,12 @@ func TestValidateImageLineFail(t *testing.T) {
 
 // test for failure of ValidateLine with broken image link
 func TestValidateImageLineFailRst(t *testing.T) {
-	line := " asldfa fasdf lsd ::image ../testfiles/img/broken.png"
+	line := ".. image:: ../testfiles/img/broken.png"
 	lineNum := 1
 	filePath := "/path/to/file.md"
-	r := internal.ExtDocRegex(".rst")
 
 	// Test your validateLine function here
-	err := internal.ValidateLine(line, lineNum, filePath, r, false)
+	err := internal.ValidateLine(line, lineNum, filePath, ".rst", internal.Options{})
 
 	// Assert that the function fails
 	if err == nil {
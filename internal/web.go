@@ -0,0 +1,238 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HTTPClient is the part of *http.Client the web checker needs. It allows
+// tests to point the checker at an httptest.Server or a stub.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// WebStatus classifies the outcome of checking a web link.
+type WebStatus int
+
+const (
+	WebOK WebStatus = iota
+	WebRedirect
+	WebClientError
+	WebServerError
+	WebTimeout
+	WebUnreachable
+)
+
+func (s WebStatus) String() string {
+	switch s {
+	case WebOK:
+		return "ok"
+	case WebRedirect:
+		return "redirect"
+	case WebClientError:
+		return "client error"
+	case WebServerError:
+		return "server error"
+	case WebTimeout:
+		return "timeout"
+	default:
+		return "unreachable"
+	}
+}
+
+// Broken reports whether the status should be treated as a broken link.
+// Redirects that were not followed by the client are not considered broken.
+func (s WebStatus) Broken() bool {
+	return s != WebOK && s != WebRedirect
+}
+
+// WebResult is the outcome of checking a single url.
+type WebResult struct {
+	URL        string
+	StatusCode int
+	Status     WebStatus
+	Err        error
+}
+
+func (r WebResult) String() string {
+	if r.Err != nil {
+		return fmt.Sprintf("%s: %v", r.Status, r.Err)
+	}
+	return fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode))
+}
+
+// WebChecker checks web links over HTTP using a bounded pool of workers.
+// The bound holds for the checker as a whole, however many files check
+// their links at once. Results are cached per url, so a url linked from many
+// places is only requested once per run.
+type WebChecker struct {
+	client  HTTPClient
+	workers int
+	timeout time.Duration
+	// slots holds a token per request in flight
+	slots chan struct{}
+
+	mu    sync.Mutex
+	cache map[string]*webEntry
+}
+
+// webEntry makes concurrent checks of the same url share one request. done
+// is closed once res is set; canceled is set when the check was cut short
+// by the context of the caller making it, which says nothing about the url.
+type webEntry struct {
+	done     chan struct{}
+	res      WebResult
+	canceled bool
+}
+
+// NewWebChecker returns a checker that issues requests through client with
+// at most workers requests in flight, each limited to timeout.
+func NewWebChecker(client HTTPClient, workers int, timeout time.Duration) *WebChecker {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return &WebChecker{
+		client:  client,
+		workers: workers,
+		timeout: timeout,
+		slots:   make(chan struct{}, workers),
+		cache:   map[string]*webEntry{},
+	}
+}
+
+// Check checks all urls and returns their results in the same order. Once
+// ctx is done the requests in flight are canceled and no more are made.
+func (c *WebChecker) Check(ctx context.Context, urls []string) []WebResult {
+	results := make([]WebResult, len(urls))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.workers && i < len(urls); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results[j] = c.checkCached(ctx, urls[j])
+			}
+		}()
+	}
+	for i := range urls {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// checkCached returns the cached result of url or checks it. Callers
+// waiting for the check of another caller whose context is done retry
+// under their own context.
+func (c *WebChecker) checkCached(ctx context.Context, url string) WebResult {
+	for {
+		c.mu.Lock()
+		entry, ok := c.cache[url]
+		if !ok {
+			entry = &webEntry{done: make(chan struct{})}
+			c.cache[url] = entry
+		}
+		c.mu.Unlock()
+
+		if !ok {
+			return c.checkEntry(ctx, url, entry)
+		}
+		select {
+		case <-entry.done:
+			if !entry.canceled {
+				return entry.res
+			}
+		case <-ctx.Done():
+			return WebResult{URL: url, Status: WebUnreachable, Err: ctx.Err()}
+		}
+	}
+}
+
+// checkEntry checks url for entry, which it removes from the cache again
+// when ctx is done before the check is.
+func (c *WebChecker) checkEntry(ctx context.Context, url string, entry *webEntry) WebResult {
+	defer close(entry.done)
+	select {
+	case c.slots <- struct{}{}:
+		entry.res = c.check(ctx, url)
+		<-c.slots
+	case <-ctx.Done():
+	}
+	if ctx.Err() != nil {
+		entry.res = WebResult{URL: url, Status: WebUnreachable, Err: ctx.Err()}
+		entry.canceled = true
+		c.mu.Lock()
+		delete(c.cache, url)
+		c.mu.Unlock()
+	}
+	return entry.res
+}
+
+func (c *WebChecker) check(ctx context.Context, url string) WebResult {
+	res := c.request(ctx, http.MethodHead, url)
+	// Plenty of servers do not implement HEAD; retry those with a GET.
+	if res.StatusCode == http.StatusMethodNotAllowed || res.StatusCode == http.StatusNotImplemented {
+		res = c.request(ctx, http.MethodGet, url)
+	}
+	return res
+}
+
+func (c *WebChecker) request(ctx context.Context, method string, url string) WebResult {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return WebResult{URL: url, Status: WebUnreachable, Err: err}
+	}
+	req.Header.Set("User-Agent", "brokenlinks")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return WebResult{URL: url, Status: classifyWebError(err), Err: err}
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	return WebResult{URL: url, StatusCode: resp.StatusCode, Status: classifyStatusCode(resp.StatusCode)}
+}
+
+func classifyStatusCode(code int) WebStatus {
+	switch {
+	case code >= 200 && code < 300:
+		return WebOK
+	case code >= 300 && code < 400:
+		return WebRedirect
+	case code >= 400 && code < 500:
+		return WebClientError
+	default:
+		return WebServerError
+	}
+}
+
+func classifyWebError(err error) WebStatus {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return WebTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return WebTimeout
+	}
+	return WebUnreachable
+}
@@ -0,0 +1,214 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/error", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/nohead", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWebCheckerClassification(t *testing.T) {
+	server := newTestServer(t)
+	checker := NewWebChecker(server.Client(), 4, 100*time.Millisecond)
+
+	tests := []struct {
+		path   string
+		status WebStatus
+	}{
+		{"/ok", WebOK},
+		{"/missing", WebClientError},
+		{"/error", WebServerError},
+		{"/moved", WebOK},
+		{"/nohead", WebOK},
+		{"/slow", WebTimeout},
+	}
+
+	urls := make([]string, len(tests))
+	for i, tt := range tests {
+		urls[i] = server.URL + tt.path
+	}
+	results := checker.Check(context.Background(), urls)
+
+	for i, tt := range tests {
+		if results[i].URL != urls[i] {
+			t.Errorf("Expected result %d for %s but got %s", i, urls[i], results[i].URL)
+		}
+		if results[i].Status != tt.status {
+			t.Errorf("Expected %s to be classified as %s, but got %s", tt.path, tt.status, results[i].Status)
+		}
+	}
+}
+
+func TestWebCheckerUnfollowedRedirect(t *testing.T) {
+	server := newTestServer(t)
+	client := server.Client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	checker := NewWebChecker(client, 1, time.Second)
+
+	res := checker.Check(context.Background(), []string{server.URL + "/moved"})[0]
+	if res.Status != WebRedirect || res.Status.Broken() {
+		t.Errorf("Expected a redirect that is not broken, but got %s", res.Status)
+	}
+}
+
+func TestWebCheckerCachesResults(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer server.Close()
+	checker := NewWebChecker(server.Client(), 2, time.Second)
+
+	checker.Check(context.Background(), []string{server.URL, server.URL})
+	checker.Check(context.Background(), []string{server.URL})
+
+	if hits != 1 {
+		t.Errorf("Expected a single request, but got %d", hits)
+	}
+}
+
+func TestCheckWebUrls(t *testing.T) {
+	var buf bytes.Buffer
+	server := newTestServer(t)
+	checker := NewWebChecker(server.Client(), 2, time.Second)
+	lineNum := 3
+	urls := []Link{
+		{Kind: WebLink, Target: server.URL + "/ok", Line: lineNum},
+		{Kind: WebLink, Target: server.URL + "/missing", Line: lineNum},
+	}
+	filePath := "/path/to/file.md"
+
+	findings := checkWebUrls(context.Background(), checker, urls, filePath)
+	WriteText(&buf, findings, true)
+	result := len(findings)
+
+	if result != 1 {
+		t.Errorf("Expected checkWebUrls to return 1, but got %d", result)
+	}
+	expectedOutput := fmt.Sprintf("\u001b[31m# broken web link in file %s:%d issue: %s (404 Not Found)\u001b[0m\n", filePath, lineNum, urls[1].Target)
+	if buf.String() != expectedOutput {
+		t.Errorf("Expected output:\n%s\nBut got:\n%s", expectedOutput, buf.String())
+	}
+}
+
+func TestWebCheckerBoundsConcurrentChecks(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+	}))
+	defer server.Close()
+	checker := NewWebChecker(server.Client(), 2, time.Second)
+
+	// Several files checking their links at once share the workers
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			checker.Check(context.Background(), []string{fmt.Sprintf("%s/%d/a", server.URL, i), fmt.Sprintf("%s/%d/b", server.URL, i)})
+		}(i)
+	}
+	wg.Wait()
+
+	if maxInFlight > 2 {
+		t.Errorf("Expected at most 2 requests in flight, but got %d", maxInFlight)
+	}
+}
+
+func TestWebCheckerCanceled(t *testing.T) {
+	server := newTestServer(t)
+	checker := NewWebChecker(server.Client(), 1, 10*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	results := checker.Check(ctx, []string{server.URL + "/slow", server.URL + "/ok"})
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the checks to stop once canceled, but they took %s", elapsed)
+	}
+	for _, res := range results {
+		if res.Status != WebUnreachable || !errors.Is(res.Err, context.Canceled) {
+			t.Errorf("Expected %s to be canceled, but got %v", res.URL, res)
+		}
+	}
+	if len(checker.cache) != 0 {
+		t.Errorf("Expected canceled checks not to be cached, but got %v", checker.cache)
+	}
+}
+
+func TestWebCheckerCanceledCallerNotShared(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first request hangs until its caller gives up
+		if atomic.AddInt32(&hits, 1) == 1 {
+			<-r.Context().Done()
+		}
+	}))
+	defer server.Close()
+	checker := NewWebChecker(server.Client(), 2, 10*time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan WebResult)
+	go func() { first <- checker.Check(ctx, []string{server.URL})[0] }()
+	for atomic.LoadInt32(&hits) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	second := make(chan WebResult)
+	go func() { second <- checker.Check(context.Background(), []string{server.URL})[0] }()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	if res := <-first; !errors.Is(res.Err, context.Canceled) {
+		t.Errorf("Expected the canceled caller to get its error, but got %v", res)
+	}
+	if res := <-second; res.Status != WebOK {
+		t.Errorf("Expected the other caller to check the url again, but got %v", res)
+	}
+	if n := atomic.LoadInt32(&hits); n != 2 {
+		t.Errorf("Expected 2 requests, but got %d", n)
+	}
+}
@@ -0,0 +1,133 @@
+// Package brokenlinks finds broken links in trees of Markdown,
+// reStructuredText, AsciiDoc and HTML documents. Validate returns what it
+// finds as structured findings, so the checker can be embedded in other
+// tools; the brokenlinks command is a renderer on top of it. Other formats
+// are added with RegisterParser.
+package brokenlinks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/erikwj/brokenlinks/internal"
+)
+
+// Finding is a single result of validating a file: a broken link, a
+// warning like an unused reference definition, or an unchecked web link.
+type Finding = internal.Finding
+
+// Severity ranks a finding.
+type Severity = internal.Severity
+
+const (
+	SeverityError   = internal.SeverityError
+	SeverityWarning = internal.SeverityWarning
+	SeverityInfo    = internal.SeverityInfo
+)
+
+// LinkKind is the kind of link a finding is about.
+type LinkKind = internal.LinkKind
+
+const (
+	FileLink          = internal.FileLink
+	WebLink           = internal.WebLink
+	InternalLink      = internal.InternalLink
+	ImageLink         = internal.ImageLink
+	ReferenceLink     = internal.ReferenceLink
+	UnusedSuppression = internal.UnusedSuppression
+)
+
+// Options controls how links are validated.
+type Options = internal.Options
+
+// Summary counts the files scanned, the links checked and the broken links
+// by kind.
+type Summary = internal.Summary
+
+// FileResult is the outcome of validating a single file.
+type FileResult = internal.FileResult
+
+// Config is the content of a .brokenlinks.yaml configuration file.
+type Config = internal.Config
+
+// WebConfig controls checking web links over HTTP.
+type WebConfig = internal.WebConfig
+
+// Configs finds the configuration of every directory of a run, see
+// LoadConfigs.
+type Configs = internal.Configs
+
+// ConfigFile is the name of the configuration files.
+const ConfigFile = internal.ConfigFile
+
+// Slugger turns heading texts into anchors, see SluggerFor.
+type Slugger = internal.Slugger
+
+// WebChecker checks web links over HTTP, see NewWebChecker.
+type WebChecker = internal.WebChecker
+
+// HTTPClient is the part of *http.Client a WebChecker needs.
+type HTTPClient = internal.HTTPClient
+
+// Link is a link found in a document, with its 1-based line and column.
+type Link = internal.Link
+
+// Parser extracts the links and anchors of a document format, see
+// RegisterParser.
+type Parser = internal.Parser
+
+var (
+	// LoadConfigs reads the configuration files of a working directory and
+	// its parents. Configuration files in other directories are read when
+	// their files are validated; override wins over all of them.
+	LoadConfigs = internal.LoadConfigs
+	// ParseConfig parses the content of a configuration file.
+	ParseConfig = internal.ParseConfig
+	// FindFiles returns the files below a root directory to validate,
+	// leaving out the excluded paths.
+	FindFiles = internal.FindFiles
+	// NewWebChecker returns a checker with at most workers requests in
+	// flight, each limited to timeout.
+	NewWebChecker = internal.NewWebChecker
+	// SluggerFor returns the Slugger constructor for a slug style, like
+	// "github" or "mkdocs".
+	SluggerFor = internal.SluggerFor
+	// SlugStyles returns the names of the supported slug styles.
+	SlugStyles = internal.SlugStyles
+	// Extensions returns the file extensions with a parser of their own.
+	// Files with any other extension are parsed as Markdown.
+	Extensions = internal.Extensions
+	// RegisterParser makes a Parser the parser of the files with the given
+	// extensions, like ".org". Call it from an init function.
+	RegisterParser = internal.RegisterParser
+	// ClassifyLink determines the kind of link for a target, for use by
+	// a Parser. Targets that cannot be checked are reported as not ok.
+	ClassifyLink = internal.ClassifyLink
+)
+
+// Validate validates the links of the documents at paths, each parsed
+// according to its extension, and returns the findings in the order of
+// paths. Files that cannot be read are skipped and reported in the error.
+// When ctx is done the findings so far are returned with the context's
+// error.
+func Validate(ctx context.Context, paths []string, opts Options) ([]Finding, error) {
+	var findings []Finding
+	var errs []error
+	ValidateFiles(ctx, paths, opts, func(res FileResult) {
+		findings = append(findings, res.Findings...)
+		if res.Err != nil && ctx.Err() == nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.Path, res.Err))
+		}
+	})
+	if err := ctx.Err(); err != nil {
+		return findings, err
+	}
+	return findings, errors.Join(errs...)
+}
+
+// ValidateFiles validates paths concurrently, like Validate, and hands the
+// result of every file to report in the order of paths.
+func ValidateFiles(ctx context.Context, paths []string, opts Options, report func(FileResult)) {
+	internal.ValidateFiles(ctx, paths, opts, report)
+}
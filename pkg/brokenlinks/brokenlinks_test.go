@@ -0,0 +1,112 @@
+package brokenlinks_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/erikwj/brokenlinks/pkg/brokenlinks"
+)
+
+func TestValidate(t *testing.T) {
+	findings, err := brokenlinks.Validate(context.Background(), []string{"../../testfiles/references.md"}, brokenlinks.Options{})
+	if err != nil {
+		t.Fatalf("Expected the file to be validated, but got error: %v", err)
+	}
+
+	expected := []brokenlinks.Finding{
+		{File: "../../testfiles/references.md", Line: 6, Column: 31, Text: "missing one", Target: "nowhere", Kind: brokenlinks.ReferenceLink, Severity: brokenlinks.SeverityError, Message: "undefined reference"},
+		{File: "../../testfiles/references.md", Line: 13, Column: 1, Text: "unused", Target: "./gone.md", Kind: brokenlinks.ReferenceLink, Severity: brokenlinks.SeverityWarning, Message: "unused reference definition"},
+	}
+	if !reflect.DeepEqual(findings, expected) {
+		t.Errorf("Expected findings:\n%v\nBut got:\n%v", expected, findings)
+	}
+}
+
+func TestValidateMissingFile(t *testing.T) {
+	paths := []string{"../../testfiles/missing.md", "../../testfiles/corrupt.md"}
+	findings, err := brokenlinks.Validate(context.Background(), paths, brokenlinks.Options{OnlyErrors: true})
+
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the missing file to be reported, but got error: %v", err)
+	}
+	if len(findings) != 6 {
+		t.Errorf("Expected the 6 broken links of corrupt.md, but got %v", findings)
+	}
+}
+
+func TestValidateCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	findings, err := brokenlinks.Validate(ctx, []string{"../../testfiles/corrupt.md"}, brokenlinks.Options{})
+	if !errors.Is(err, context.Canceled) || len(findings) != 0 {
+		t.Errorf("Expected no findings and context.Canceled, but got %v and %v", findings, err)
+	}
+}
+
+// orgParser is a minimal Org-mode parser: [[target][text]] links and
+// headings as anchors.
+type orgParser struct{}
+
+var (
+	orgLinkRegex    = regexp.MustCompile(`\[\[([^\]]+)\]\[([^\]]*)\]\]`)
+	orgHeadingRegex = regexp.MustCompile(`(?m)^\*+ (.+)$`)
+)
+
+func (orgParser) Links(source []byte) []brokenlinks.Link {
+	var links []brokenlinks.Link
+	for i, line := range strings.Split(string(source), "\n") {
+		for _, m := range orgLinkRegex.FindAllStringSubmatchIndex(line, -1) {
+			target := strings.TrimPrefix(line[m[2]:m[3]], "file:")
+			if kind, ok := brokenlinks.ClassifyLink(target, false); ok {
+				links = append(links, brokenlinks.Link{Kind: kind, Text: line[m[4]:m[5]], Target: target, Line: i + 1, Column: m[0] + 1})
+			}
+		}
+	}
+	return links
+}
+
+func (orgParser) Anchors(source []byte, slugger brokenlinks.Slugger) []string {
+	var anchors []string
+	for _, m := range orgHeadingRegex.FindAllSubmatch(source, -1) {
+		anchors = append(anchors, slugger.Slug(string(m[1])))
+	}
+	return anchors
+}
+
+func TestRegisterParser(t *testing.T) {
+	if err := brokenlinks.RegisterParser(orgParser{}, "org"); err == nil {
+		t.Errorf("Expected an extension without a dot to be refused")
+	}
+	if err := brokenlinks.RegisterParser(orgParser{}, ".org"); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := brokenlinks.Validate(context.Background(), []string{"../../testfiles/org/index.org"}, brokenlinks.Options{OnlyErrors: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"gone.org", "#nowhere"}
+	if len(findings) != len(expected) {
+		t.Fatalf("Expected %d broken links, but got %v", len(expected), findings)
+	}
+	for i, target := range expected {
+		if findings[i].Target != target {
+			t.Errorf("Expected a broken link to %s, but got %v", target, findings[i])
+		}
+	}
+
+	found := false
+	for _, ext := range brokenlinks.Extensions() {
+		found = found || ext == ".org"
+	}
+	if !found {
+		t.Errorf("Expected .org to be listed in %v", brokenlinks.Extensions())
+	}
+}
@@ -0,0 +1,2 @@
+# Sphinx configuration of the reStructuredText test project
+project = "brokenlinks"